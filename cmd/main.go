@@ -4,11 +4,17 @@ import (
 	"carlosapi/pkg/color"
 	"carlosapi/pkg/config"
 	"carlosapi/pkg/controllers"
+	"carlosapi/pkg/database"
+	"carlosapi/pkg/rotator"
 	"carlosapi/pkg/routes"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -19,6 +25,7 @@ func main() {
 
 	// init
 	config.NoRecording()
+	controllers.Recover()
 
 	// create HTTP routes
 	router := mux.NewRouter()
@@ -39,9 +46,68 @@ ___/\/\/\/\/\__/\/\____/\/\__/\/\____/\/\__/\/\/\/\/\____/\/\/\/\____/\/\/\/\/\_
 	fmt.Fprintf(os.Stderr, color.Cyan + logo + color.Reset)
 	log.Printf("📡 " + color.Green + "CarlosAPI version " + color.Purple + "%s" + color.Green + " listening on port " + color.Yellow + "%d" + color.Reset, conf.Version, conf.Port)
 
+	// expose the simulated rotator to other tools
+	if conf.RotatorBackend == rotator.BackendSimulated && conf.RotatorSim.Listen != "" {
+		server, err := rotator.NewRotctldServer(conf.RotatorSim.Listen, rotator.Simulated(conf.RotatorSim))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("🧭 " + color.Green + "Simulated rotctld listening on " + color.Yellow + "%v" + color.Reset, server.Addr())
+		go server.Serve()
+	}
+
 	// run sheduler on another thread
 	go controllers.RunScheduling()
 
 	addr := fmt.Sprintf("%s:%d", conf.Addr, conf.Port) 
-	log.Fatal(http.ListenAndServe(addr, router))
+	server := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// wait for Ctrl-C or systemd
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	shutdown(conf, server)
+}
+
+// stops cleanly: no new recordings, the running one is checkpointed and its
+// SDR closed, the rotor parked, then the HTTP server and the database
+func shutdown(conf config.Config, server *http.Server) {
+	log.Printf("🛑 " + color.Yellow + "Shutting down" + color.Reset)
+
+	timeout := time.Duration(conf.ShutdownTimeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	controllers.Shutdown(timeout)
+
+	// park
+	rot, err := rotator.NewRotator(conf)
+	if err == nil {
+		log.Printf("🅿️  Parking rotator")
+		err = rot.Park()
+		rot.Close()
+	}
+	if err != nil {
+		log.Printf("❌ Error parking rotator: %v", err)
+	}
+
+	// let the requests being served finish
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("❌ Error stopping HTTP server: %v", err)
+	}
+
+	err = database.CloseDB()
+	if err != nil {
+		log.Printf("❌ Error closing database: %v", err)
+	}
+	log.Printf("👋 " + color.Green + "Bye" + color.Reset)
 }
@@ -0,0 +1,131 @@
+package astro
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fails unless got is within tolerance of want, angles wrap at 360
+func near(t *testing.T, name string, got float64, want float64, tolerance float64) {
+	t.Helper()
+	d := math.Mod(math.Abs(got-want), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	if d > tolerance {
+		t.Errorf("%s = %.6f, want %.6f (within %g)", name, got, want, tolerance)
+	}
+}
+
+// hours, minutes and seconds to degrees
+func hms(h, m, s float64) float64 {
+	return (h + m/60 + s/3600) * 15
+}
+
+// degrees, minutes and seconds to degrees
+func dms(d, m, s float64) float64 {
+	sign := 1.0
+	if d < 0 {
+		sign, d = -1, -d
+	}
+	return sign * (d + m/60 + s/3600)
+}
+
+func TestJulianDate(t *testing.T) {
+	// J2000.0 and Meeus example 7.a, 1957 October 4.81
+	near(t, "J2000", JulianDate(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)), J2000, 1e-9)
+	sputnik := time.Date(1957, 10, 4, 19, 26, 24, 0, time.UTC)
+	near(t, "Sputnik", JulianDate(sputnik), 2436116.31, 1e-6)
+	// a double holds Julian dates to a few microseconds
+	if back := Time(JulianDate(sputnik)); back.Sub(sputnik).Abs() > time.Millisecond {
+		t.Errorf("Time(JulianDate(%v)) = %v", sputnik, back)
+	}
+}
+
+func TestGMST(t *testing.T) {
+	// Meeus examples 12.a and 12.b
+	near(t, "GMST 1987-04-10 0h", GMST(time.Date(1987, 4, 10, 0, 0, 0, 0, time.UTC)), 197.693195, 1e-5)
+	near(t, "GMST 1987-04-10 19:21", GMST(time.Date(1987, 4, 10, 19, 21, 0, 0, time.UTC)), 128.7378734, 1e-5)
+	// Greenwich is 0 east
+	at := time.Date(1987, 4, 10, 0, 0, 0, 0, time.UTC)
+	near(t, "LST 90E", LST(at, 90), 197.693195+90, 1e-5)
+}
+
+func TestPrecess(t *testing.T) {
+	// Meeus example 21.b, theta Persei with its proper motion applied
+	// from J2000.0 to 2028 November 13.19
+	years := (2462088.69 - J2000) / 365.25
+	ra := hms(2, 44, 11.986) + 0.03425*years/240
+	dec := dms(49, 13, 42.48) - 0.0895*years/3600
+	ra, dec = Precess(ra, dec, J2000, 2462088.69)
+	near(t, "RA", ra, hms(2, 46, 11.331), 1e-4)
+	near(t, "Dec", dec, dms(49, 20, 54.54), 1e-4)
+
+	// and back
+	ra, dec = Precess(ra, dec, 2462088.69, J2000)
+	near(t, "RA back", ra, hms(2, 44, 11.986)+0.03425*years/240, 1e-4)
+	near(t, "Dec back", dec, dms(49, 13, 42.48)-0.0895*years/3600, 1e-4)
+}
+
+func TestHorizontal(t *testing.T) {
+	// Meeus example 13.b, Venus from the US Naval Observatory, azimuths
+	// there are from the south, the small difference is the nutation in
+	// the apparent sidereal time used by Meeus
+	usno := Observer{Lat: dms(38, 55, 17), Lon: -dms(77, 3, 56), Location: time.UTC}
+	at := time.Date(1987, 4, 10, 19, 21, 0, 0, time.UTC)
+	az, el := usno.HorizontalOfDate(hms(23, 9, 16.641), dms(-6, 43, 11.61), at)
+	near(t, "Az", az, 68.0337+180, 0.01)
+	near(t, "El", el, 15.1249, 0.01)
+}
+
+func TestEquatorialRoundTrip(t *testing.T) {
+	madrid := Observer{Lat: 40.4, Lon: -3.7, Location: time.UTC}
+	at := time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC)
+	for _, c := range [][2]float64{{350.85, 58.815}, {83.633, 22.015}, {266.417, -29.008}, {10, 89}} {
+		az, el := madrid.Horizontal(c[0], c[1], at)
+		ra, dec := madrid.Equatorial(az, el, at)
+		near(t, "RA", ra, c[0], 1e-6)
+		near(t, "Dec", dec, c[1], 1e-6)
+	}
+}
+
+func TestSunEquatorial(t *testing.T) {
+	// Meeus example 25.a, 1992 October 13.0 TD, to the accuracy of the
+	// low precision formulas
+	ra, dec := SunEquatorial(time.Date(1992, 10, 12, 23, 59, 1, 0, time.UTC))
+	near(t, "RA", ra, hms(13, 13, 31.4), 0.01)
+	near(t, "Dec", dec, dms(-7, 47, 1), 0.01)
+}
+
+func TestMoonEquatorial(t *testing.T) {
+	// Meeus example 47.a, 1992 April 12 0h TD, to the accuracy of the low
+	// precision formulas
+	ra, dec, parallax := MoonEquatorial(time.Date(1992, 4, 11, 23, 59, 1, 0, time.UTC))
+	near(t, "RA", ra, 134.688470, 0.3)
+	near(t, "Dec", dec, 13.768368, 0.3)
+	near(t, "Parallax", parallax, 0.991990, 0.01)
+}
+
+func TestGalactic(t *testing.T) {
+	// the galactic center and the north galactic pole
+	ra, dec := GalacticToEquatorial(0, 0)
+	near(t, "RA center", ra, hms(17, 45, 37.2), 0.001)
+	near(t, "Dec center", dec, dms(-28, 56, 10.2), 0.001)
+	ra, dec = GalacticToEquatorial(0, 90)
+	near(t, "RA pole", ra, 192.85948, 1e-6)
+	near(t, "Dec pole", dec, 27.12825, 1e-6)
+
+	// Cas A
+	l, b := EquatorialToGalactic(350.85, 58.815)
+	near(t, "l Cas A", l, 111.74, 0.01)
+	near(t, "b Cas A", b, -2.13, 0.01)
+	ra, dec = GalacticToEquatorial(l, b)
+	near(t, "RA Cas A", ra, 350.85, 1e-6)
+	near(t, "Dec Cas A", dec, 58.815, 1e-6)
+}
+
+func TestSeparation(t *testing.T) {
+	near(t, "pole to equator", Separation(0, 90, 123, 0), 90, 1e-9)
+	near(t, "across north", Separation(359, 10, 1, 10), 1.9696, 1e-4)
+}
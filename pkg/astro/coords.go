@@ -0,0 +1,99 @@
+package astro
+
+import (
+	"carlosapi/pkg/config"
+	"math"
+	"time"
+)
+
+const rad = math.Pi / 180
+
+// Observer is a place on Earth, degrees with longitude east positive,
+// altitude in meters above sea level
+type Observer struct {
+	Lat      float64
+	Lon      float64
+	Alt      float64
+	Location *time.Location
+}
+
+// the station of the configuration, UTC if its timezone is not set or not
+// known
+func NewObserver(conf config.StationConfig) Observer {
+	loc, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return Observer{Lat: conf.Latitude, Lon: conf.Longitude, Alt: conf.Altitude, Location: loc}
+}
+
+// LST is the local mean sidereal time at the observer in degrees
+func (o Observer) LST(t time.Time) float64 {
+	return LST(t, o.Lon)
+}
+
+// Horizontal converts J2000 right ascension and declination (degrees) to
+// azimuth (from north through east) and elevation (degrees) at time t,
+// precessing them to the date first
+func (o Observer) Horizontal(ra float64, dec float64, t time.Time) (float64, float64) {
+	ra, dec = Precess(ra, dec, J2000, JulianDate(t))
+	return o.HorizontalOfDate(ra, dec, t)
+}
+
+// HorizontalOfDate converts right ascension and declination of the date
+// (degrees) to azimuth and elevation (degrees) at time t
+func (o Observer) HorizontalOfDate(ra float64, dec float64, t time.Time) (float64, float64) {
+	h := (o.LST(t) - ra) * rad
+	phi := o.Lat * rad
+	d := dec * rad
+	el := math.Asin(math.Sin(phi)*math.Sin(d) + math.Cos(phi)*math.Cos(d)*math.Cos(h))
+	az := math.Atan2(-math.Sin(h)*math.Cos(d), math.Cos(phi)*math.Sin(d)-math.Sin(phi)*math.Cos(d)*math.Cos(h))
+	return Normalize(az / rad), el / rad
+}
+
+// Equatorial converts azimuth and elevation (degrees) at time t to J2000
+// right ascension and declination (degrees)
+func (o Observer) Equatorial(az float64, el float64, t time.Time) (float64, float64) {
+	ra, dec := o.EquatorialOfDate(az, el, t)
+	return Precess(ra, dec, JulianDate(t), J2000)
+}
+
+// EquatorialOfDate converts azimuth and elevation (degrees) at time t to
+// right ascension and declination of the date (degrees)
+func (o Observer) EquatorialOfDate(az float64, el float64, t time.Time) (float64, float64) {
+	phi := o.Lat * rad
+	a := az * rad
+	e := el * rad
+	dec := math.Asin(math.Sin(phi)*math.Sin(e) + math.Cos(phi)*math.Cos(e)*math.Cos(a))
+	h := math.Atan2(-math.Sin(a)*math.Cos(e), math.Cos(phi)*math.Sin(e)-math.Sin(phi)*math.Cos(e)*math.Cos(a))
+	return Normalize(o.LST(t) - h/rad), dec / rad
+}
+
+// Precess moves right ascension and declination (degrees) from the
+// equinox of one Julian date to another (Meeus 21.2 and 21.3)
+func Precess(ra float64, dec float64, fromJD float64, toJD float64) (float64, float64) {
+	if fromJD == toJD {
+		return ra, dec
+	}
+	T := centuries(fromJD)
+	t := (toJD - fromJD) / 36525
+	// arcseconds
+	zeta := (2306.2181+1.39656*T-0.000139*T*T)*t + (0.30188-0.000344*T)*t*t + 0.017998*t*t*t
+	z := (2306.2181+1.39656*T-0.000139*T*T)*t + (1.09468+0.000066*T)*t*t + 0.018203*t*t*t
+	theta := (2004.3109-0.85330*T-0.000217*T*T)*t - (0.42665+0.000217*T)*t*t - 0.041833*t*t*t
+	zeta, z, theta = zeta/3600*rad, z/3600*rad, theta/3600*rad
+
+	a := ra*rad + zeta
+	d := dec * rad
+	A := math.Cos(d) * math.Sin(a)
+	B := math.Cos(theta)*math.Cos(d)*math.Cos(a) - math.Sin(theta)*math.Sin(d)
+	C := math.Sin(theta)*math.Cos(d)*math.Cos(a) + math.Cos(theta)*math.Sin(d)
+	return Normalize((math.Atan2(A, B) + z) / rad), math.Asin(math.Max(-1, math.Min(1, C))) / rad
+}
+
+// Separation is the angle in degrees between two directions given as
+// longitude and latitude pairs (az/el, ra/dec, l/b)
+func Separation(lon1, lat1, lon2, lat2 float64) float64 {
+	c := math.Sin(lat1*rad)*math.Sin(lat2*rad) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Cos((lon1-lon2)*rad)
+	return math.Acos(math.Max(-1, math.Min(1, c))) / rad
+}
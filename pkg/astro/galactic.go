@@ -0,0 +1,33 @@
+package astro
+
+import "math"
+
+// J2000 equatorial coordinates of the north galactic pole and galactic
+// longitude of the north celestial pole, degrees
+const (
+	poleRa  = 192.85948
+	poleDec = 27.12825
+	poleL   = 122.93192
+)
+
+// GalacticToEquatorial converts galactic longitude and latitude to J2000
+// right ascension and declination, degrees
+func GalacticToEquatorial(l float64, b float64) (float64, float64) {
+	d := poleDec * rad
+	bb := b * rad
+	dl := (poleL - l) * rad
+	dec := math.Asin(math.Sin(d)*math.Sin(bb) + math.Cos(d)*math.Cos(bb)*math.Cos(dl))
+	ra := math.Atan2(math.Cos(bb)*math.Sin(dl), math.Cos(d)*math.Sin(bb)-math.Sin(d)*math.Cos(bb)*math.Cos(dl))
+	return Normalize(ra/rad + poleRa), dec / rad
+}
+
+// EquatorialToGalactic converts J2000 right ascension and declination to
+// galactic longitude and latitude, degrees
+func EquatorialToGalactic(ra float64, dec float64) (float64, float64) {
+	d := poleDec * rad
+	dd := dec * rad
+	da := (ra - poleRa) * rad
+	b := math.Asin(math.Sin(d)*math.Sin(dd) + math.Cos(d)*math.Cos(dd)*math.Cos(da))
+	l := math.Atan2(math.Cos(dd)*math.Sin(da), math.Cos(d)*math.Sin(dd)-math.Sin(d)*math.Cos(dd)*math.Cos(da))
+	return Normalize(poleL - l/rad), b / rad
+}
@@ -0,0 +1,41 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// MoonEquatorial returns the geocentric right ascension and declination of
+// the date of the Moon and its horizontal parallax, degrees at time t,
+// accurate to about 0.3 degrees (Astronomical Almanac low precision
+// formulas)
+func MoonEquatorial(t time.Time) (float64, float64, float64) {
+	T := centuries(JulianDate(t))
+	sin := func(deg float64) float64 { return math.Sin(deg * rad) }
+	cos := func(deg float64) float64 { return math.Cos(deg * rad) }
+
+	// ecliptic longitude, latitude and horizontal parallax
+	lambda := 218.32 + 481267.881*T +
+		6.29*sin(135.0+477198.87*T) - 1.27*sin(259.3-413335.36*T) +
+		0.66*sin(235.7+890534.22*T) + 0.21*sin(269.9+954397.74*T) -
+		0.19*sin(357.5+35999.05*T) - 0.11*sin(186.5+966404.03*T)
+	beta := 5.13*sin(93.3+483202.02*T) + 0.28*sin(228.2+960400.89*T) -
+		0.28*sin(318.3+6003.15*T) - 0.17*sin(217.6-407332.21*T)
+	parallax := 0.9508 + 0.0518*cos(135.0+477198.87*T) +
+		0.0095*cos(259.3-413335.36*T) + 0.0078*cos(235.7+890534.22*T) +
+		0.0028*cos(269.9+954397.74*T)
+
+	// direction cosines in the equatorial frame
+	l := cos(beta) * cos(lambda)
+	m := 0.9175*cos(beta)*sin(lambda) - 0.3978*sin(beta)
+	n := 0.3978*cos(beta)*sin(lambda) + 0.9175*sin(beta)
+	return Normalize(math.Atan2(m, l) / rad), math.Asin(n) / rad, parallax
+}
+
+// Moon returns the azimuth and elevation of the Moon in degrees at time t,
+// seen from the observer (lowered by the parallax, up to a degree)
+func (o Observer) Moon(t time.Time) (float64, float64) {
+	ra, dec, parallax := MoonEquatorial(t)
+	az, el := o.HorizontalOfDate(ra, dec, t)
+	return az, el - math.Asin(math.Sin(parallax*rad)*math.Cos(el*rad))/rad
+}
@@ -0,0 +1,64 @@
+package astro
+
+import "time"
+
+// how often the elevation is sampled looking for events, and how long
+// ahead, a bit over a day so the Moon transits too
+const (
+	eventStep   = 10 * time.Minute
+	eventWindow = 25 * time.Hour
+)
+
+// Events returns the next rise and set through elevation h0 (degrees) and
+// the next transit (highest elevation) after from, of something whose
+// elevation at a time is given by el, to the second. Times are zero for
+// events that don't happen in the next 25 hours, such as rising for
+// something that is always up.
+func Events(el func(time.Time) float64, from time.Time, h0 float64) (rise time.Time, set time.Time, transit time.Time) {
+	prev, prevEl := from, el(from)
+	// elevation was going up at prev
+	rising := el(from.Add(time.Second)) > prevEl
+	for t := from.Add(eventStep); !t.After(from.Add(eventWindow)); t = t.Add(eventStep) {
+		e := el(t)
+		if rise.IsZero() && prevEl < h0 && e >= h0 {
+			rise = crossing(el, prev, t, h0)
+		}
+		if set.IsZero() && prevEl >= h0 && e < h0 {
+			set = crossing(el, prev, t, h0)
+		}
+		if transit.IsZero() && rising && e < prevEl {
+			transit = highest(el, prev.Add(-eventStep), t)
+		}
+		rising = e > prevEl
+		prev, prevEl = t, e
+	}
+	return rise, set, transit
+}
+
+// time in [a, b] the elevation crosses h0, which it does once
+func crossing(el func(time.Time) float64, a time.Time, b time.Time, h0 float64) time.Time {
+	up := el(b) >= h0
+	for b.Sub(a) > time.Second {
+		m := a.Add(b.Sub(a) / 2)
+		if (el(m) >= h0) == up {
+			b = m
+		} else {
+			a = m
+		}
+	}
+	return b.Round(time.Second)
+}
+
+// time in [a, b] of the highest elevation, which only has one maximum
+func highest(el func(time.Time) float64, a time.Time, b time.Time) time.Time {
+	for b.Sub(a) > time.Second {
+		third := b.Sub(a) / 3
+		m1, m2 := a.Add(third), b.Add(-third)
+		if el(m1) < el(m2) {
+			a = m1
+		} else {
+			b = m2
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Round(time.Second)
+}
@@ -0,0 +1,30 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// SunEquatorial returns the right ascension and declination of the date
+// of the Sun in degrees at time t, accurate to about 0.01 degrees
+// (Astronomical Almanac low precision formulas)
+func SunEquatorial(t time.Time) (float64, float64) {
+	// days since J2000.0
+	n := JulianDate(t) - J2000
+
+	// ecliptic longitude and obliquity
+	L := 280.460 + 0.9856474*n
+	g := (357.528 + 0.9856003*n) * rad
+	lambda := (L + 1.915*math.Sin(g) + 0.020*math.Sin(2*g)) * rad
+	eps := (23.439 - 0.0000004*n) * rad
+
+	ra := math.Atan2(math.Cos(eps)*math.Sin(lambda), math.Cos(lambda))
+	dec := math.Asin(math.Sin(eps) * math.Sin(lambda))
+	return Normalize(ra / rad), dec / rad
+}
+
+// Sun returns the azimuth and elevation of the Sun in degrees at time t
+func (o Observer) Sun(t time.Time) (float64, float64) {
+	ra, dec := SunEquatorial(t)
+	return o.HorizontalOfDate(ra, dec, t)
+}
@@ -0,0 +1,52 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Julian dates of the Unix epoch and of the J2000.0 epoch
+const (
+	unixEpochJD = 2440587.5
+	J2000       = 2451545.0
+)
+
+// JulianDate of a time, UTC is used for UT1 (less than a second apart)
+func JulianDate(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + unixEpochJD
+}
+
+// Time of a Julian date, in UTC
+func Time(jd float64) time.Time {
+	days := jd - unixEpochJD
+	return time.Unix(0, int64(math.Round(days*float64(24*time.Hour)))).UTC()
+}
+
+// julian centuries since J2000.0
+func centuries(jd float64) float64 {
+	return (jd - J2000) / 36525
+}
+
+// GMST is the Greenwich mean sidereal time in degrees [0, 360) (Meeus
+// 12.4)
+func GMST(t time.Time) float64 {
+	jd := JulianDate(t)
+	T := centuries(jd)
+	gmst := 280.46061837 + 360.98564736629*(jd-J2000) + 0.000387933*T*T - T*T*T/38710000
+	return Normalize(gmst)
+}
+
+// LST is the local mean sidereal time in degrees [0, 360) at a longitude
+// (degrees, east positive)
+func LST(t time.Time, lon float64) float64 {
+	return Normalize(GMST(t) + lon)
+}
+
+// Normalize returns an angle in degrees in [0, 360)
+func Normalize(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
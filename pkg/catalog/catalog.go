@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"carlosapi/pkg/astro"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// kinds of targets
+const (
+	KindSun      = "sun"
+	KindMoon     = "moon"
+	KindSource   = "radio source"
+	KindGalactic = "galactic plane"
+)
+
+// Target is a named object in the sky, J2000 right ascension and
+// declination in degrees for the fixed ones
+type Target struct {
+	Name string
+	Kind string
+	Ra   float64
+	Dec  float64
+}
+
+// the built-in catalog, galactic plane points every 30 degrees of
+// longitude are added on init
+var targets = []Target{
+	{Name: "Sun", Kind: KindSun},
+	{Name: "Moon", Kind: KindMoon},
+	{Name: "Cas A", Kind: KindSource, Ra: 350.850, Dec: 58.815},
+	{Name: "Cyg A", Kind: KindSource, Ra: 299.868, Dec: 40.734},
+	{Name: "Tau A", Kind: KindSource, Ra: 83.633, Dec: 22.015},
+	{Name: "Sgr A*", Kind: KindSource, Ra: 266.417, Dec: -29.008},
+}
+
+func init() {
+	for l := 0; l < 360; l += 30 {
+		ra, dec := astro.GalacticToEquatorial(float64(l), 0)
+		targets = append(targets, Target{Name: fmt.Sprintf("Galactic %d", l), Kind: KindGalactic, Ra: ra, Dec: dec})
+	}
+}
+
+// All returns the targets of the catalog
+func All() []Target {
+	return targets
+}
+
+// Lookup finds a target by name, ignoring case and spaces
+func Lookup(name string) (Target, bool) {
+	key := canonical(name)
+	for _, t := range targets {
+		if canonical(t.Name) == key {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
+
+func canonical(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+}
+
+// Equatorial returns the J2000 right ascension and declination of the
+// target at a time, degrees
+func (t Target) Equatorial(at time.Time) (float64, float64) {
+	switch t.Kind {
+	case KindSun:
+		ra, dec := astro.SunEquatorial(at)
+		return astro.Precess(ra, dec, astro.JulianDate(at), astro.J2000)
+	case KindMoon:
+		ra, dec, _ := astro.MoonEquatorial(at)
+		return astro.Precess(ra, dec, astro.JulianDate(at), astro.J2000)
+	}
+	return t.Ra, t.Dec
+}
+
+// Horizontal returns the azimuth and elevation of the target seen by an
+// observer at a time, degrees
+func (t Target) Horizontal(o astro.Observer, at time.Time) (float64, float64) {
+	switch t.Kind {
+	case KindSun:
+		return o.Sun(at)
+	case KindMoon:
+		return o.Moon(at)
+	}
+	return o.Horizontal(t.Ra, t.Dec, at)
+}
+
+// Events returns the next rise and set of the target through elevation h0
+// (degrees) and its next transit seen by an observer after a time, zero if
+// they don't happen in the next day
+func (t Target) Events(o astro.Observer, from time.Time, h0 float64) (time.Time, time.Time, time.Time) {
+	return astro.Events(func(at time.Time) float64 {
+		_, el := t.Horizontal(o, at)
+		return el
+	}, from, h0)
+}
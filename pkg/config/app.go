@@ -16,9 +16,115 @@ type Config struct {
 	RecordCmd   string  `toml:"record_cmd"`
 	RecordArgs  string  `toml:"record_args"`
 	Database	string  `toml:"database"`
+	SdrBackend  string  `toml:"sdr_backend"`
+	RecoveryPolicy string   `toml:"recovery_policy"`
+	ScheduleConflicts string `toml:"schedule_conflicts"`
+	BlackoutPolicy string   `toml:"blackout_policy"`
+	ShutdownTimeout int64   `toml:"shutdown_timeout"`
+	Simulator   SimulatorConfig `toml:"simulator"`
+	RtlTcp      RtlTcpConfig    `toml:"rtltcp"`
+	RotatorBackend string       `toml:"rotator_backend"`
+	Rotator     RotatorConfig   `toml:"rotator"`
+	Rotctld     RotctldConfig   `toml:"rotctld"`
+	RotatorSim  RotatorSimConfig `toml:"rotator_sim"`
+	Station     StationConfig   `toml:"station"`
+	Constraints ConstraintsConfig `toml:"constraints"`
 	Version     string
 }
 
+// a continuous wave injected by the simulated SDR
+type SimTone struct {
+	Offset		float64 `toml:"offset"`		// Hz from the center frequency
+	Amplitude	float64 `toml:"amplitude"`	// ADC counts at 0 dB gain
+}
+
+// parameters of the simulated SDR backend
+type SimulatorConfig struct {
+	Seed				int64		`toml:"seed"`
+	Realtime			bool		`toml:"realtime"`
+	NoiseFloor			float64		`toml:"noise_floor"`			// ADC counts at 0 dB gain
+	Tones				[]SimTone	`toml:"tones"`
+	HydrogenAmplitude	float64		`toml:"hydrogen_amplitude"`	// ADC counts at 0 dB gain
+	HydrogenWidth		float64		`toml:"hydrogen_width"`		// Hz
+	HydrogenAz			float64		`toml:"hydrogen_az"`			// direction of the strongest emission
+	HydrogenEl			float64		`toml:"hydrogen_el"`
+	HydrogenBeam		float64		`toml:"hydrogen_beam"`		// degrees
+}
+
+// rtl_tcp server sharing a remote dongle
+type RtlTcpConfig struct {
+	Host		string	`toml:"host"`
+	Port		int		`toml:"port"`
+	Timeout		int64	`toml:"timeout"`	// milliseconds
+}
+
+// how rotator movements are supervised and how fast the rotator is, speeds
+// in degrees per second and accelerations in degrees per second squared
+type RotatorConfig struct {
+	Tolerance		float64	`toml:"tolerance"`		// degrees
+	PollInterval	int64	`toml:"poll_interval"`	// milliseconds
+	MoveTimeout		int64	`toml:"move_timeout"`	// milliseconds
+	AzSpeed			float64	`toml:"az_speed"`
+	ElSpeed			float64	`toml:"el_speed"`
+	AzAccel			float64	`toml:"az_accel"`
+	ElAccel			float64	`toml:"el_accel"`
+	TrackInterval	int64	`toml:"track_interval"`	// milliseconds between re-pointings at sky targets
+	// mount limits in the rotator frame, max_az above 360 for rotators with
+	// overlap, all 0 for 0-360 and 0-90
+	MinAz			float64	`toml:"min_az"`
+	MaxAz			float64	`toml:"max_az"`
+	MinEl			float64	`toml:"min_el"`
+	MaxEl			float64	`toml:"max_el"`
+}
+
+// hamlib rotctld daemon driving the rotator
+type RotctldConfig struct {
+	Host		string	`toml:"host"`
+	Port		int		`toml:"port"`
+	Timeout		int64	`toml:"timeout"`	// milliseconds
+}
+
+// simulated rotator, speeds in degrees per second, accelerations in degrees
+// per second squared
+type RotatorSimConfig struct {
+	AzSpeed		float64	`toml:"az_speed"`
+	ElSpeed		float64	`toml:"el_speed"`
+	AzAccel		float64	`toml:"az_accel"`
+	ElAccel		float64	`toml:"el_accel"`
+	MinAz		float64	`toml:"min_az"`
+	MaxAz		float64	`toml:"max_az"`
+	MinEl		float64	`toml:"min_el"`
+	MaxEl		float64	`toml:"max_el"`
+	ParkAz		float64	`toml:"park_az"`
+	ParkEl		float64	`toml:"park_el"`
+	Stuck		bool	`toml:"stuck"`	// never moves, to reproduce timeouts
+	Listen		string	`toml:"listen"`	// also serve it as rotctld on this address
+}
+
+// where the telescope is, degrees, longitude positive east
+type StationConfig struct {
+	Latitude	float64	`toml:"latitude"`
+	Longitude	float64	`toml:"longitude"`
+	Altitude	float64	`toml:"altitude"`	// meters above sea level
+	Timezone	string	`toml:"timezone"`	// IANA name, e.g. "Europe/Madrid"
+}
+
+// an azimuth sector blocked up to an elevation (buildings, trees), from_az
+// to to_az clockwise
+type KeepOut struct {
+	FromAz		float64	`toml:"from_az"`
+	ToAz		float64	`toml:"to_az"`
+	MaxEl		float64	`toml:"max_el"`	// 0 for the whole sector
+}
+
+// where the dish must not point, degrees
+type ConstraintsConfig struct {
+	Policy			string		`toml:"policy"`			// "reject" or "clip"
+	MinElevation	float64		`toml:"min_elevation"`
+	SunSeparation	float64		`toml:"sun_separation"`	// 0 to disable
+	KeepOut			[]KeepOut	`toml:"keep_out"`
+}
+
 // atomic so is thread safe
 var recording atomic.Bool
 
@@ -0,0 +1,59 @@
+package constraints
+
+import (
+	"carlosapi/pkg/astro"
+	"carlosapi/pkg/config"
+	"fmt"
+	"time"
+)
+
+// what to do with recordings pointing where they must not ([constraints]
+// policy in config.toml)
+const (
+	PolicyReject = "reject"
+	PolicyClip   = "clip"
+)
+
+// Check returns why the dish must not point at az/el at time t, or nil
+func Check(conf config.Config, az float64, el float64, t time.Time) error {
+	return check(conf, az, el, t, true)
+}
+
+// CheckSun is Check for observing the Sun on purpose, which doesn't keep
+// away from it
+func CheckSun(conf config.Config, az float64, el float64, t time.Time) error {
+	return check(conf, az, el, t, false)
+}
+
+func check(conf config.Config, az float64, el float64, t time.Time, avoidSun bool) error {
+	c := conf.Constraints
+	if el < c.MinElevation {
+		return fmt.Errorf("Elevation %.1f below the minimum %.1f", el, c.MinElevation)
+	}
+	for _, k := range c.KeepOut {
+		if inSector(az, k.FromAz, k.ToAz) && (k.MaxEl <= 0 || el < k.MaxEl) {
+			return fmt.Errorf("Azimuth %.1f in keep-out sector %.1f-%.1f", az, k.FromAz, k.ToAz)
+		}
+	}
+	if avoidSun && c.SunSeparation > 0 {
+		sunAz, sunEl := astro.NewObserver(conf.Station).Sun(t)
+		// no danger while it is below the horizon
+		if sunEl > 0 {
+			if sep := astro.Separation(az, el, sunAz, sunEl); sep < c.SunSeparation {
+				return fmt.Errorf("%.1f degrees from the Sun, %.1f needed", sep, c.SunSeparation)
+			}
+		}
+	}
+	return nil
+}
+
+// is an azimuth in the sector going clockwise from "from" to "to"?
+func inSector(az float64, from float64, to float64) bool {
+	az = astro.Normalize(az)
+	from, to = astro.Normalize(from), astro.Normalize(to)
+	if from <= to {
+		return az >= from && az <= to
+	}
+	// crosses north
+	return az >= from || az <= to
+}
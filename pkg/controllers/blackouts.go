@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// POST "/blackouts" declares a period the station must not observe, takes
+// "start", "end" (milliseconds) and "reason"
+// recordings already queued in it wait until it ends
+func CreateBlackout(writer http.ResponseWriter, request *http.Request) {
+	blackout := &models.Blackout{}
+	err := utils.ParseBody(request, blackout)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	err = blackout.Check()
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	blackout.Id = blackoutIds.next()
+	blackout.Create()
+	if ids := conflicts(reservations(config.GetConfig(), 0), blackout.Start, blackout.End); len(ids) > 0 {
+		log.Printf("⚠️ "+color.Yellow+" Blackout overlaps %v\n"+color.Reset, ids)
+	}
+	// the scheduler has to wake up at its start and end
+	notify(0)
+
+	log.Printf("🚧"+color.Blue+" Added blackout %v\n"+color.Reset, blackout.Id)
+	writeJSON(writer, http.StatusOK, blackout)
+}
+
+// GET "/blackouts" returns all the blackout windows
+func GetBlackouts(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, models.GetBlackouts())
+}
+
+// DELETE "/blackouts/id" removes a blackout window
+func DeleteBlackout(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	id, err := strconv.ParseInt(vars["id"], 0, 0)
+	if err != nil {
+		log.Printf("❌ ID Parse Error %v\n", err.Error())
+		writeError(writer, http.StatusBadRequest, "Problem parsing ID")
+		return
+	}
+
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	blackout, result := models.GetBlackoutById(id)
+	if result.Error != nil {
+		writeError(writer, http.StatusNotFound, "No blackout with that ID")
+		return
+	}
+	blackout.Delete()
+	notify(0)
+
+	log.Printf("🗑️ "+color.Blue+" Deleted blackout %v\n"+color.Reset, blackout.Id)
+	writeJSON(writer, http.StatusOK, blackout)
+}
@@ -3,29 +3,56 @@ package controllers
 import (
 	"carlosapi/pkg/color"
 	"carlosapi/pkg/config"
-	"carlosapi/pkg/database"
 	"carlosapi/pkg/models"
+	"carlosapi/pkg/rotator"
 	"carlosapi/pkg/utils"
 	"carlosapi/pkg/sdrcarlos"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
-var updateChannel chan models.Notification
+// writes a JSON response
+func writeJSON(writer http.ResponseWriter, status int, v any) {
+	res, _ := json.Marshal(v)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	writer.Write(res)
+}
 
-func init() {
-	updateChannel = make(chan models.Notification)
+// writes a JSON error response
+func writeError(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, status, map[string]string{"error": message})
 }
 
-func GetChannel() chan models.Notification {
-	return updateChannel
+// gives ids made of the creation time in milliseconds, or one more than
+// the last one if it got that already
+type idAllocator struct {
+	last int64
+}
+
+// ids of recordings, series, blackout windows and surveys, guarded by
+// recordingsMu
+var recordingIds, seriesIds, blackoutIds, surveyIds idAllocator
+
+// id for something new
+// to be called with recordingsMu held
+func (a *idAllocator) next() int64 {
+	id := time.Now().UnixMilli()
+	if id <= a.last {
+		id = a.last + 1
+	}
+	a.last = id
+	return id
 }
 
 // "/" return configuration parameters
@@ -67,6 +94,8 @@ func GetStatusId(writer http.ResponseWriter, request *http.Request) {
 		writer.Write([]byte(`{"error": "No recording with that ID"}`))
 		return
 	}
+	recording.LoadHistory()
+	recording.LoadTrajectory()
 	
 	res, _ := json.Marshal(recording)
 	writer.Header().Set("Content-Type", "application/json")
@@ -87,6 +116,9 @@ func ClearDatabase(writer http.ResponseWriter, request *http.Request) {
 // creates a new recording
 // TODO: validate fields
 func CreateRecording(writer http.ResponseWriter, request *http.Request) {
+	if !acceptingJobs(writer) {
+		return
+	}
 	// parse JSON
 	newRecording := &models.Recording{}
 	err := utils.ParseBody(request, newRecording)
@@ -106,13 +138,33 @@ func CreateRecording(writer http.ResponseWriter, request *http.Request) {
 		writer.Write([]byte(res))
 		return		
 	}
+	// check it fits in the schedule
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if !checkConflicts(writer, config.GetConfig(), newRecording) {
+		return
+	}
+	if len(newRecording.Conflicts) > 0 {
+		log.Printf("⚠️ " + color.Yellow + " Overlaps %v\n" + color.Reset, newRecording.Conflicts)
+	}
+	if !checkPointing(writer, config.GetConfig(), newRecording) {
+		return
+	}
+
 	// ok, create recording
-	newRecording.Id = time.Now().UnixMilli()
+	newRecording.Id = recordingIds.next()
 	newRecording.Status = models.Created
+	newRecording.SeriesId = 0
+	newRecording.SurveyId = 0
+	newRecording.Attempts = 0
+	newRecording.OriginalTime = 0
+	newRecording.ScanRaStart = 0
+	newRecording.ScanRaEnd = 0
+	newRecording.ScanDec = 0
 	recording := newRecording.CreateRecording()
 
 	// send notification
-	updateChannel <- models.Notification{}
+	notify(recording.Id)
 
 	// ok
 	log.Printf("📝" + color.Blue + " Added %v\n" + color.Reset, recording.Id)
@@ -150,7 +202,12 @@ func DownloadId(writer http.ResponseWriter, request *http.Request) {
 		writer.Write([]byte(`{"error": "No recording with requested ID"}`))
 		return
 	}
-	if recording.Status != models.Finished {
+	_, err = os.Stat(conf.RecordPath + varid + ".tar.gz")
+	if recording.Status == models.Failed || (recording.Status == models.Cancelled && err != nil) {
+		writeError(writer, http.StatusGone, fmt.Sprintf("Recording %s: %s", recording.Status, recording.Error))
+		return
+	}
+	if recording.Status != models.Finished && recording.Status != models.Cancelled && recording.Status != models.Partial {
 		writer.Header().Set("Content-Type", "application/json")
 		writer.WriteHeader(http.StatusLocked)
 		writer.Write([]byte(`{"error": "Recording not done yet"}`))
@@ -162,141 +219,525 @@ func DownloadId(writer http.ResponseWriter, request *http.Request) {
 	http.ServeFile(writer, request, conf.RecordPath + varid + ".tar.gz")
 }
 
-// Scheduler, checks for due recordings and launches them
-// launched on another thread
-func RunScheduling() {
-	log.Println("⏰" + color.Yellow + " Starting Scheduler" + color.Reset)
+// returned when a recording stops to let a higher priority one run or for
+// a blackout window
+var errPreempted = errors.New("Paused for a higher priority recording or a blackout")
+
+// returned when a recording is interrupted because the program is stopping
+var errShutdown = errors.New("Interrupted by a shutdown")
+
+// the recording being captured, how to cancel or preempt it and when it's
+// done
+var running struct {
+	sync.Mutex
+	id        int64
+	priority  int
+	preempted bool
+	// closed when it's asked to stop at the next point
+	preempt chan struct{}
+	cancel  context.CancelCauseFunc
+	done    chan struct{}
+}
 
-	// need to update?
-	updateDatabase := true
+// how long a recording interrupted by a shutdown may take to let go
+const shutdownGrace = 10 * time.Second
+
+// registers the recording about to run, returns its context
+func startRunning(rec *models.Recording) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	running.Lock()
+	defer running.Unlock()
+	running.id = rec.Id
+	running.priority = rec.Priority
+	running.preempted = false
+	running.preempt = make(chan struct{})
+	running.cancel = cancel
+	running.done = make(chan struct{})
+	return ctx
+}
 
-	// get db
-	db := database.GetDB()
+// asks the running recording to stop at the next point
+// to be called with running locked
+func markPreempted() {
+	if !running.preempted {
+		running.preempted = true
+		close(running.preempt)
+	}
+}
 
-	// recordings array
-	var newRecordings []models.Recording
-	
-	for {
-		// check channel
+// a channel closed when the running recording is asked to stop at the
+// next point
+func preemption() chan struct{} {
+	running.Lock()
+	defer running.Unlock()
+	return running.preempt
+}
+
+// asks the running recording to stop at the next point if its priority is
+// lower, returns true if it was asked
+func preemptRunning(priority int) bool {
+	running.Lock()
+	defer running.Unlock()
+	if running.cancel == nil || running.preempted || running.priority >= priority {
+		return false
+	}
+	markPreempted()
+	return true
+}
+
+// asks the running recording to stop at the next point whatever its
+// priority, returns true if it was asked
+func pauseRunning() bool {
+	running.Lock()
+	defer running.Unlock()
+	if running.cancel == nil || running.preempted {
+		return false
+	}
+	markPreempted()
+	return true
+}
+
+// has the running recording been asked to stop?
+func isPreempted() bool {
+	running.Lock()
+	defer running.Unlock()
+	return running.preempted
+}
+
+// unregisters the running recording, unless another one has been
+// registered since
+func stopRunning(id int64) {
+	running.Lock()
+	defer running.Unlock()
+	if running.id != id || running.cancel == nil {
+		return
+	}
+	running.cancel(nil)
+	close(running.done)
+	running.id = 0
+	running.preempted = false
+	running.preempt = nil
+	running.cancel = nil
+	running.done = nil
+}
+
+// cancels a running recording, returns a channel closed when it's done or
+// nil if that recording isn't running
+func cancelRunning(id int64) chan struct{} {
+	running.Lock()
+	defer running.Unlock()
+	if running.id != id || running.cancel == nil {
+		return nil
+	}
+	running.cancel(nil)
+	return running.done
+}
+
+// asks the running recording to stop after its current point, it goes
+// back to the queue to continue later, returns how to interrupt it and
+// what tells it stopped, nil if nothing is running
+func checkpointRunning() (context.CancelCauseFunc, chan struct{}) {
+	running.Lock()
+	defer running.Unlock()
+	if running.cancel == nil {
+		return nil, nil
+	}
+	markPreempted()
+	return running.cancel, running.done
+}
+
+// waits for a checkpointed recording to stop, interrupts it after the
+// timeout
+func awaitCheckpoint(timeout time.Duration, cancel context.CancelCauseFunc, done chan struct{}) {
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("⏱️  Interrupting the current point\n")
+		cancel(errShutdown)
 		select {
-		case <- updateChannel:
-			// get from database again
-			updateDatabase = true
-		default:
-			// nothing
+		case <-done:
+		case <-time.After(shutdownGrace):
+			log.Printf("❌ Recording still running after %v, leaving it\n", shutdownGrace)
 		}
+	}
+}
 
-		// need to update?
-		if updateDatabase {
-			// get requests not done yet
-			db.Where("status=?", models.Created).Find(&newRecordings)
-			updateDatabase = false
-		}
-		
-		for _, rec := range newRecordings {
-			if rec.Time < time.Now().UnixMilli() && !config.IsRecording() {
-				log.Printf("⚡" + color.Yellow + " Launching %v\n" + color.Reset, rec.Id)
-				rec.Status = models.Running
-				rec.Update()
-				config.Recording()
-				go RunProcess(rec)
-				// we need to update to see the change of a finished recording
-				updateDatabase = true
-				break
-			}
+// runs the recording, puts it back in the queue if it failed and may be
+// retried
+// launched on another thread
+func RunProcess(ctx context.Context, rec models.Recording) {
+	attempt := models.Attempt{RecordingId: rec.Id, Point: -1, Start: time.Now().UnixMilli()}
+	err := record(ctx, &rec)
+	switch {
+	case errors.Is(err, errPreempted), errors.Is(err, errShutdown):
+		// back to the queue, it will continue from the next point
+		log.Printf("⏸️  Paused %v\n", rec.Id)
+		rec.Status = models.Created
+		rec.Error = ""
+		attempt.Result = models.AttemptPaused
+	case errors.Is(err, context.Canceled):
+		log.Printf("🛑 Cancelled %v\n", rec.Id)
+		rec.Status = models.Cancelled
+		rec.Error = "Cancelled while running"
+	case err != nil:
+		rec.Attempts++
+		rec.Error = err.Error()
+		if retry, ok := retryTime(&rec); ok {
+			// back to the queue, it will continue from the failed point
+			log.Printf("🔁 Recording %v failed: %v, retrying at %v\n", rec.Id, err, time.UnixMilli(retry).Format(time.TimeOnly))
+			rec.Status = models.Created
+			rec.Time = retry
+			attempt.Result = models.AttemptRetrying
+			break
 		}
-		time.Sleep(1 * time.Second)
+		log.Printf("❌ Recording %v failed: %v\n", rec.Id, err)
+		rec.Status = models.Failed
+	default:
+		log.Printf("✅ Finishing %v\n", rec.Id)
+		rec.Status = models.Finished
+		rec.Error = ""
+	}
+	// update recording status and history
+	rec.Update()
+	attempt.End = time.Now().UnixMilli()
+	attempt.Error = rec.Error
+	if attempt.Result == "" {
+		attempt.Result = string(rec.Status)
 	}
+	attempt.Create()
+	// not recording anymore, unregistered first so the next one launched
+	// can't be taken for this one
+	stopRunning(rec.Id)
+	config.NoRecording()
+	notify(rec.Id)
 }
 
+// when a failed run may be retried, false if it has no attempts left or
+// it would start too late
+func retryTime(rec *models.Recording) (int64, bool) {
+	if rec.Attempts >= rec.RunAttempts {
+		return 0, false
+	}
+	retry := time.Now().Add(rec.RetryDelay(rec.Attempts)).UnixMilli()
+	if retry - rec.OriginalTime > rec.MaxSlip {
+		return 0, false
+	}
+	return retry, true
+}
 
-// runs the recording
-// launched on another thread
-// TODO: do it for real
-func RunProcess(rec models.Recording) {
+// moves the rotor over the grid of the recording and captures every point,
+// then archives the data, if ctx is cancelled archives what was captured
+// and returns the context error
+func record(ctx context.Context, rec *models.Recording) error {
 	conf := config.GetConfig()
 
 	// get and configure SDR
-	carlosDev := &sdrcarlos.SDRCARLOS{Debug: false}
-
-	devices := carlosDev.GetDevices()
-	if devices == nil {
-		log.Println("❌ Can't find any RTLSDR devices")
+	carlosDev, err := sdrcarlos.NewReceiver(conf)
+	if err != nil {
+		return fmt.Errorf("SDR backend failed: %v", err)
+	}
+	defer carlosDev.Close()
+	if carlosDev.GetDevices() == nil {
+		return fmt.Errorf("Can't find any SDR devices")
 	}
 
 	// use first device
 	indexID := 0
-	err := carlosDev.Config(indexID, rec.SampleRate, rec.Frequency, 0, rec.Gain, true)
+	err = carlosDev.Config(indexID, rec.SampleRate, rec.Frequency, 0, rec.Gain, true)
 	if err != nil {
-		log.Printf("❌ SDR configure failed: %s\n", err.Error())
+		return fmt.Errorf("SDR configure failed: %v", err)
 	}
-	
-	// args := fmt.Sprintf(conf.RecordCmd,
-	// 	rec.SampleRate, rec.Frequency, rec.Gain, rec.RecTime,
-	// 	rec.WaitTime, rec.Az, rec.El, rec.AzRange, rec.ElRange,
-	// 	rec.AzStep, rec.ElStep, conf.RecordPath + strconv.FormatInt(rec.Id, 10) + ".iq")
 
-	// log.Println("Args: ", args)
-	
 	// create output dir
 	err = os.MkdirAll(conf.RecordPath + strconv.FormatInt(rec.Id, 10), 0755)
 	if err != nil && !os.IsExist(err) {
-		log.Println("❌ Error creating output directory")
-		log.Println(err.Error())
-	}
-
-	// no errors and SDR detected?
-	if err == nil && devices != nil {
-		// move rotor to starting point
-		// TODO
-		
-		// ranges
-		for az := rec.Az - rec.AzRange/2; az <= rec.Az + rec.AzRange/2; az += rec.AzStep {
-			for el := rec.El - rec.ElRange/2; el <= rec.El + rec.ElRange/2; el += rec.ElStep {
-				// TODO move rotor
-				
-				log.Printf("🔴 Recording: (%3.1f, %3.1f)\n", az, el)
-
-				// record
-				carlosDev.ReadTime(fmt.Sprintf("%s/%d/%d-%3.1f-%3.1f.iq",
-					conf.RecordPath, rec.Id, rec.Id, az, el), rec.RecTime)
+		return fmt.Errorf("Error creating output directory: %v", err)
+	}
+
+	// get rotator
+	rot, err := rotator.NewRotator(conf)
+	if err != nil {
+		return fmt.Errorf("Rotator backend failed: %v", err)
+	}
+	defer rot.Close()
+
+	// time spent moving the rotor
+	var slewing time.Duration
+
+	// positions, skipping the ones already recorded
+	completed := completedPoints(conf, rec)
+	if len(completed) > 0 {
+		log.Printf("⏩ Resuming, %d points already captured\n", len(completed))
+	}
+	// points left out by the constraints
+	skipped := 0
+	grid := rec.Grid()
+
+	// rotator azimuths of the points, keeping the cable wrap in mind
+	from, _, err := rot.GetPosition()
+	if err != nil {
+		from = grid[0].Az
+	}
+	limits := rotator.MountLimits(conf)
+	path := limits.Path(from, azimuths(grid))
+	preempt := preemption()
+grid:
+	for i, point := range grid {
+		if _, ok := completed[i]; ok {
+			continue
+		}
+		// let a higher priority recording run, the data stays for later
+		if isPreempted() {
+			return errPreempted
+		}
+		// sky targets are where they are now, from the turn of the cable
+		// wrap closest to the rotator
+		sky := rec.PointingAt(point, time.Now())
+		rotorAz := path[i]
+		if rec.Tracked() {
+			if az, _, err := rot.GetPosition(); err == nil {
+				from = az
 			}
+			rotorAz = limits.Path(from, []float32{sky.Az})[0]
 		}
-		
-		// create compressed archive
-		log.Printf("🗜️  Creating compressed archive.\n")
-		dirname := fmt.Sprintf("%s%d/", conf.RecordPath, rec.Id)
-		directory, err := os.Open(dirname)
-		if err != nil {
-			log.Println("❌ Error getting output directory")
+		// never point where it must not, the sky may have moved since it
+		// was scheduled
+		if err := checkSky(conf, sky, time.Now(), rec.SunTarget()); err != nil {
+			log.Printf("⛔ Skipping (%3.1f, %3.1f): %v\n", sky.Az, sky.El, err)
+			now := time.Now().UnixMilli()
+			skip := models.Attempt{
+				RecordingId: rec.Id,
+				Point: i,
+				Start: now,
+				End: now,
+				Result: models.AttemptSkipped,
+				Error: err.Error(),
+			}
+			skip.Create()
+			skipped++
+			continue
 		}
-		defer directory.Close()
-		files, err := directory.Readdirnames(0)
-		if err != nil {
-			log.Println("❌ Error listing data files")
+		// try the point, again after a while if it fails
+		var filename string
+		for try := 1; ; try++ {
+			start := time.Now()
+			var slew time.Duration
+			filename, slew, err = capturePoint(ctx, conf, rec, carlosDev, rot, i, point, sky, rotorAz)
+			slewing += slew
+			if err == nil || ctx.Err() != nil || try >= rec.PointAttempts {
+				break
+			}
+			delay := rec.RetryDelay(try)
+			log.Printf("🔁 Point (%3.1f, %3.1f) failed: %v, retrying in %v\n", point.Az, point.El, err, delay)
+			failed := models.Attempt{
+				RecordingId: rec.Id,
+				Point: i,
+				Start: start.UnixMilli(),
+				End: time.Now().UnixMilli(),
+				Result: models.AttemptRetrying,
+				Error: err.Error(),
+			}
+			failed.Create()
+			// a shutdown or a blackout doesn't wait for the backoff
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			case <-preempt:
+				return errPreempted
+			}
+		}
+		if ctx.Err() != nil {
+			break grid
 		}
-		err = utils.CreateArchive(fmt.Sprintf("%s/%d.tar.gz", conf.RecordPath, rec.Id), dirname, files)
 		if err != nil {
-			log.Printf("❌ Error creating compressed archive: %v", err)
+			return err
 		}
 
-		// remove uncompressed data
-		log.Printf("🗑️  Removing uncompressed data.\n")
-		err = os.RemoveAll(dirname)
+		// save progress so it can be resumed from the next point
+		info, err := os.Stat(filename)
 		if err != nil {
-			log.Printf("❌ Error deleting uncompressed data: %v", err)
+			return fmt.Errorf("Capture failed at (%3.1f, %3.1f): %v", point.Az, point.El, err)
+		}
+		progress := models.PointProgress{
+			RecordingId: rec.Id,
+			Point: i,
+			Az: point.Az,
+			El: point.El,
+			File: rec.DataFile(point),
+			Bytes: info.Size(),
 		}
+		progress.Create()
 	}
-	
-	// out, err := exec.Command(conf.RecordCmd, args).Output()
-    // if err != nil {
-	// 	log.Println("❌ Error running record command")
-    //     log.Println(err.Error() + "\n\n" + string(out))
-    // }
-	log.Printf("✅ Finishing %v\n", rec.Id)
-	// update recording status
-	rec.Status = models.Finished
-	rec.Update()
-	// not recording anymore
-	config.NoRecording()
+	// interrupted by a shutdown, keep the data to continue later
+	if errors.Is(context.Cause(ctx), errShutdown) {
+		return errShutdown
+	}
+	log.Printf("🧭 Rotator slewing took %v\n", slewing.Round(time.Second))
+	if skipped == len(grid) {
+		return fmt.Errorf("All points break the pointing constraints")
+	}
+
+	err = archive(conf, rec.Id)
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// moves the rotor to a point of the grid, looking at sky az/el from rotorAz
+// in the rotator frame, and captures it following the target if it is in
+// the sky, returns the data file and the time spent moving, if ctx is
+// cancelled returns its error
+func capturePoint(ctx context.Context, conf config.Config, rec *models.Recording, carlosDev sdrcarlos.Receiver, rot rotator.Rotator, i int, point models.Pointing, sky models.Pointing, rotorAz float32) (string, time.Duration, error) {
+	az, el := sky.Az, sky.El
+
+	// move rotor and wait to get there
+	log.Printf("🧭 Moving to: (%3.1f, %3.1f)\n", az, el)
+	moveStart := time.Now()
+	err := rotator.MoveTo(ctx, rot, rotorAz, el, conf.Rotator)
+	slewing := time.Since(moveStart)
+	if ctx.Err() != nil {
+		return "", slewing, ctx.Err()
+	}
+	if err != nil {
+		return "", slewing, fmt.Errorf("Rotator failed at (%3.1f, %3.1f): %v", az, el, err)
+	}
+	// let it settle
+	select {
+	case <-time.After(time.Duration(rec.WaitTime) * time.Millisecond):
+	case <-ctx.Done():
+		return "", slewing, ctx.Err()
+	}
+
+	log.Printf("🔴 Recording: (%3.1f, %3.1f)\n", az, el)
+
+	// follow the target and save where the antenna is while recording,
+	// drift scans get timestamp markers too
+	filename := fmt.Sprintf("%s%d/%s", conf.RecordPath, rec.Id, rec.DataFile(point))
+	rec.ClearPointTrajectory(i)
+	// simulated receivers know where we are looking from the first sample,
+	// tracking only updates it
+	if pointer, ok := carlosDev.(sdrcarlos.Pointer); ok {
+		pointer.SetPointing(az, el)
+	}
+	tracking, stopTracking := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		track(tracking, conf, rec, carlosDev, rot, i, point, rotorAz)
+	}()
+	start := time.Now()
+	if rec.Mode == models.ModeDrift {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			markDrift(tracking, conf, rec, carlosDev, filename, sky, start)
+		}()
+	}
+
+	// record
+	err = carlosDev.ReadTime(ctx, filename, rec.RecTime)
+	stopTracking()
+	wg.Wait()
+	if err != nil {
+		return "", slewing, fmt.Errorf("Capture failed at (%3.1f, %3.1f): %v", az, el, err)
+	}
+	if rec.Mode == models.ModeDrift {
+		driftCoverage(conf, rec, sky, captureStart(carlosDev, start), time.Now())
+	}
+	return filename, slewing, ctx.Err()
+}
+
+// re-points the rotor at a sky target every track_interval until ctx is
+// done, the antenna position is saved to the trajectory of the recording
+// then and at the start and the end, fixed recordings are only sampled
+func track(ctx context.Context, conf config.Config, rec *models.Recording, carlosDev sdrcarlos.Receiver, rot rotator.Rotator, i int, point models.Pointing, rotorAz float32) {
+	interval := time.Duration(conf.Rotator.TrackInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	limits := rotator.MountLimits(conf)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		sky := rec.PointingAt(point, now)
+		if rec.Tracked() {
+			// stays where it is rather than following the target where it
+			// must not point
+			if err := checkSky(conf, sky, now, rec.SunTarget()); err != nil {
+				log.Printf("⛔ Not following to (%3.1f, %3.1f): %v\n", sky.Az, sky.El, err)
+			} else {
+				rotorAz = limits.Path(rotorAz, []float32{sky.Az})[0]
+				if err := rot.SetPosition(rotorAz, sky.El); err != nil {
+					log.Printf("❌ Tracking failed at (%3.1f, %3.1f): %v\n", sky.Az, sky.El, err)
+				}
+			}
+		}
+		// let simulated receivers know where we are looking now
+		if pointer, ok := carlosDev.(sdrcarlos.Pointer); ok {
+			pointer.SetPointing(sky.Az, sky.El)
+		}
+		sampleTrajectory(rec, rot, i, sky, now)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			// where it ended
+			now = time.Now()
+			sampleTrajectory(rec, rot, i, rec.PointingAt(point, now), now)
+			return
+		}
+	}
+}
+
+// saves where the antenna is and where it should be to the trajectory of a
+// recording
+func sampleTrajectory(rec *models.Recording, rot rotator.Rotator, i int, sky models.Pointing, t time.Time) {
+	az, el, err := rot.GetPosition()
+	if err != nil {
+		return
+	}
+	sample := models.TrajectoryPoint{
+		RecordingId: rec.Id,
+		Point: i,
+		Time: t.UnixMilli(),
+		Az: rotator.NormalizeAz(az),
+		El: el,
+		TargetAz: sky.Az,
+		TargetEl: sky.El,
+	}
+	sample.Create()
+}
+
+// creates the compressed archive of a recording and removes the
+// uncompressed data
+func archive(conf config.Config, id int64) error {
+	log.Printf("🗜️  Creating compressed archive.\n")
+	dirname := fmt.Sprintf("%s%d/", conf.RecordPath, id)
+	directory, err := os.Open(dirname)
+	if err != nil {
+		return fmt.Errorf("Error getting output directory: %v", err)
+	}
+	defer directory.Close()
+	files, err := directory.Readdirnames(0)
+	if err != nil {
+		return fmt.Errorf("Error listing data files: %v", err)
+	}
+	err = utils.CreateArchive(fmt.Sprintf("%s/%d.tar.gz", conf.RecordPath, id), dirname, files)
+	if err != nil {
+		return fmt.Errorf("Error creating compressed archive: %v", err)
+	}
+
+	// remove uncompressed data
+	log.Printf("🗑️  Removing uncompressed data.\n")
+	err = os.RemoveAll(dirname)
+	if err != nil {
+		log.Printf("❌ Error deleting uncompressed data: %v", err)
+	}
+	return nil
 }
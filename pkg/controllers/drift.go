@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"carlosapi/pkg/astro"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/sdrcarlos"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// suffix of the timestamp markers file written next to the data file of a
+// drift scan
+const markerSuffix = ".markers"
+
+// writes a timestamp marker every marker_interval of the recording (10 s by
+// default) while a drift scan started at "start" is captured, and one when
+// ctx is done: time, sample, local sidereal time and the J2000 right
+// ascension and declination the beam is looking at. Samples are counted by
+// the receiver from the first one it writes when it can, otherwise they
+// are guessed from the clock
+func markDrift(ctx context.Context, conf config.Config, rec *models.Recording, carlosDev sdrcarlos.Receiver, filename string, sky models.Pointing, start time.Time) {
+	file, err := os.Create(filename + markerSuffix)
+	if err != nil {
+		log.Printf("❌ Error creating the markers file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	interval := time.Duration(rec.MarkerInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	station := astro.NewObserver(conf.Station)
+	mark := func(t time.Time, sample int64) {
+		ra, dec := station.Equatorial(float64(sky.Az), float64(sky.El), t)
+		fmt.Fprintf(file, "%d,%d,%.6f,%.4f,%.4f\n", t.UnixMilli(), sample, station.LST(t)/15, ra, dec)
+	}
+	fmt.Fprintf(file, "# time_ms,sample,lst_hours,ra,dec\n")
+
+	// the receiver keeps the pace of the clock
+	origin := start
+	samples := func(t time.Time) int64 {
+		return int64(t.Sub(origin).Seconds() * float64(rec.SampleRate))
+	}
+	if counter, ok := carlosDev.(sdrcarlos.Counter); ok {
+		first, ok := firstSample(ctx, counter, start)
+		if !ok {
+			return
+		}
+		origin = first
+		samples = func(time.Time) int64 {
+			n, _ := counter.Captured()
+			return n
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	mark(origin, 0)
+	for {
+		select {
+		case t := <-ticker.C:
+			mark(t, samples(t))
+		case <-ctx.Done():
+			t := time.Now()
+			mark(t, samples(t))
+			return
+		}
+	}
+}
+
+// waits for the receiver to write the first sample of a capture started
+// after "start" and returns when it did, false if ctx is done before
+func firstSample(ctx context.Context, counter sdrcarlos.Counter, start time.Time) (time.Time, bool) {
+	poll := time.NewTicker(10 * time.Millisecond)
+	defer poll.Stop()
+	for {
+		// a zero time or one before the start is from no capture yet, or
+		// the previous one
+		if _, first := counter.Captured(); !first.Before(start) {
+			return first, true
+		}
+		select {
+		case <-poll.C:
+		case <-ctx.Done():
+			return time.Time{}, false
+		}
+	}
+}
+
+// when the first sample of a capture started after "start" was written, or
+// "start" if the receiver doesn't tell
+func captureStart(carlosDev sdrcarlos.Receiver, start time.Time) time.Time {
+	if counter, ok := carlosDev.(sdrcarlos.Counter); ok {
+		if _, first := counter.Captured(); !first.Before(start) {
+			return first
+		}
+	}
+	return start
+}
+
+// saves the strip of sky a drift scan captured between two times, the J2000
+// right ascensions the beam went through and its declination
+func driftCoverage(conf config.Config, rec *models.Recording, sky models.Pointing, start time.Time, end time.Time) {
+	station := astro.NewObserver(conf.Station)
+	rec.ScanRaStart, rec.ScanDec = station.Equatorial(float64(sky.Az), float64(sky.El), start)
+	rec.ScanRaEnd, _ = station.Equatorial(float64(sky.Az), float64(sky.El), end)
+	log.Printf("🌌 Drift scan covered RA %.2f to %.2f at dec %.2f\n", rec.ScanRaStart, rec.ScanRaEnd, rec.ScanDec)
+}
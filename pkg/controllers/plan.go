@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/constraints"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/rotator"
+	"carlosapi/pkg/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+// a position of the grid as it would be recorded, times in milliseconds
+type PlanPoint struct {
+	Az      float32 `json:"az"`
+	El      float32 `json:"el"`
+	File    string  `json:"file"`
+	Slew    int64   `json:"slew_time"`
+	Wait    int64   `json:"wait_time"`
+	Capture int64   `json:"capture_time"`
+	Bytes   int64   `json:"bytes"`
+	Start   int64   `json:"start"`
+	End     int64   `json:"end"`
+	// why it breaks the pointing constraints at that time
+	Violation string `json:"violation,omitempty"`
+}
+
+// what a recording would do, times in milliseconds, start and end are
+// projected after the recordings already queued and the blackout windows
+type Plan struct {
+	Points     []PlanPoint `json:"points"`
+	Slew       int64       `json:"slew_time"`
+	Wait       int64       `json:"wait_time"`
+	Capture    int64       `json:"capture_time"`
+	Duration   int64       `json:"duration"`
+	Bytes      int64       `json:"bytes"`
+	Start      int64       `json:"start"`
+	End        int64       `json:"end"`
+	Conflicts  []int64     `json:"conflicts,omitempty"`
+	Blackouts  []int64     `json:"blackouts,omitempty"`
+	Violations int         `json:"violations,omitempty"`
+}
+
+// plans the points of a recording starting at its time, the slew time of
+// the first point is not known as it depends on where the rotator is
+func makePlan(conf config.Config, rec *models.Recording) Plan {
+	model := rotator.EstimateModel(conf)
+	var plan Plan
+	t := rec.Time
+	grid := rec.Grid()
+	limits := rotator.MountLimits(conf)
+	path := limits.Path(grid[0].Az, azimuths(grid))
+	var prevAz, prevEl float32
+	for i, point := range grid {
+		// sky targets are followed from where they are when the point starts
+		sky := rec.PointingAt(point, time.UnixMilli(t))
+		rotorAz := path[i]
+		if rec.Tracked() {
+			if i == 0 {
+				prevAz = sky.Az
+			}
+			rotorAz = limits.Path(prevAz, []float32{sky.Az})[0]
+		}
+		p := PlanPoint{
+			Az:      sky.Az,
+			El:      sky.El,
+			File:    rec.DataFile(point),
+			Wait:    rec.WaitTime,
+			Capture: rec.RecTime,
+			// 2 bytes (I and Q) per sample
+			Bytes: int64(rec.SampleRate) * rec.RecTime / 1000 * 2,
+			Start: t,
+		}
+		// the rotor stops at the mount limits
+		rotorEl := limits.ClampEl(sky.El)
+		if i > 0 {
+			p.Slew = model.Time(prevAz, prevEl, rotorAz, rotorEl).Milliseconds()
+		}
+		prevAz, prevEl = rotorAz, rotorEl
+		t += p.Slew + p.Wait + p.Capture
+		p.End = t
+		if err := checkSky(conf, sky, time.UnixMilli(p.End-p.Capture), rec.SunTarget()); err != nil {
+			p.Violation = err.Error()
+			plan.Violations++
+		}
+
+		plan.Points = append(plan.Points, p)
+		plan.Slew += p.Slew
+		plan.Wait += p.Wait
+		plan.Capture += p.Capture
+		plan.Bytes += p.Bytes
+	}
+	plan.Duration = plan.Slew + plan.Wait + plan.Capture
+	plan.Start = rec.Time
+	plan.End = rec.Time + plan.Duration
+	return plan
+}
+
+// why the dish can't look at sky az/el at a time, breaking the pointing
+// constraints or out of the mount limits, nil if it can. Observing the Sun
+// doesn't keep the sun separation
+func checkSky(conf config.Config, sky models.Pointing, t time.Time, sun bool) error {
+	check := constraints.Check
+	if sun {
+		check = constraints.CheckSun
+	}
+	if err := check(conf, float64(sky.Az), float64(sky.El), t); err != nil {
+		return err
+	}
+	return rotator.MountLimits(conf).CheckEl(sky.El)
+}
+
+// grid points breaking the pointing constraints at the time they would be
+// captured
+func (p *Plan) violations() []PlanPoint {
+	var points []PlanPoint
+	for _, point := range p.Points {
+		if point.Violation != "" {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// grid points of a recording breaking the pointing constraints at its time
+// when it has to be rejected for them, with the clip policy only if no
+// point is left, nil if it can go ahead
+func rejectedPoints(conf config.Config, rec *models.Recording) []PlanPoint {
+	plan := makePlan(conf, rec)
+	violations := plan.violations()
+	if len(violations) == 0 {
+		return nil
+	}
+	if conf.Constraints.Policy == constraints.PolicyClip && len(violations) < len(plan.Points) {
+		log.Printf("✂️ "+color.Yellow+" %d points will be left out by the constraints\n"+color.Reset, len(violations))
+		return nil
+	}
+	return violations
+}
+
+// checks the grid of a recording against the pointing constraints at its
+// time, returns false and writes the error response if it has to be
+// rejected, with the clip policy only if no point is left
+func checkPointing(writer http.ResponseWriter, conf config.Config, rec *models.Recording) bool {
+	violations := rejectedPoints(conf, rec)
+	if len(violations) == 0 {
+		return true
+	}
+
+	writeJSON(writer, http.StatusBadRequest, struct {
+		Error      string      `json:"error"`
+		Violations []PlanPoint `json:"violations"`
+	}{
+		Error:      "Points break the pointing constraints",
+		Violations: violations,
+	})
+	return false
+}
+
+// azimuths of the points of a grid
+func azimuths(grid []models.Pointing) []float32 {
+	azs := make([]float32, len(grid))
+	for i, p := range grid {
+		azs[i] = p.Az
+	}
+	return azs
+}
+
+// POST "/plan" returns what a recording would do without creating it, takes
+// the same JSON as "/record"
+func PlanRecording(writer http.ResponseWriter, request *http.Request) {
+	rec := &models.Recording{}
+	err := utils.ParseBody(request, rec)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	// as soon as possible if no time is given
+	if rec.Time == 0 {
+		rec.Time = time.Now().Add(time.Second).UnixMilli()
+	}
+	err = rec.Check()
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// project it after the recordings already queued and the blackouts,
+	// planned again at that time as sky targets and the Sun move
+	conf := config.GetConfig()
+	plan := makePlan(conf, rec)
+	res := blocking(reservations(conf, 0), rec)
+	closed := blackouts()
+	ids := conflicts(res, plan.Start, plan.End)
+	closedIds := conflicts(closed, plan.Start, plan.End)
+	if slot := nextFreeSlot(merge(res, closed), plan.Start, plan.Duration); slot != rec.Time {
+		rec.Time = slot
+		plan = makePlan(conf, rec)
+	}
+	plan.Conflicts, plan.Blackouts = ids, closedIds
+
+	writeJSON(writer, http.StatusOK, plan)
+}
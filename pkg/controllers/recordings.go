@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// how long a cancel request waits for the recording to stop and archive
+const cancelTimeout = 60 * time.Second
+
+// gets the recording identified by the "id" in the URL, writes the error
+// response and returns nil if it can't
+func getRecording(writer http.ResponseWriter, request *http.Request) *models.Recording {
+	vars := mux.Vars(request)
+	id, err := strconv.ParseInt(vars["id"], 0, 0)
+	if err != nil {
+		log.Printf("❌ ID Parse Error %v\n", err.Error())
+		writeError(writer, http.StatusBadRequest, "Problem parsing ID")
+		return nil
+	}
+	recording, result := models.GetRecordingById(id)
+	if result.Error != nil {
+		writeError(writer, http.StatusNotFound, "No recording with that ID")
+		return nil
+	}
+	return recording
+}
+
+// DELETE "/recordings/id" removes a recording that is not running and its data
+func DeleteRecording(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	recording := getRecording(writer, request)
+	if recording == nil {
+		return
+	}
+	if recording.Status == models.Running {
+		writeError(writer, http.StatusConflict, "Recording is running, cancel it first")
+		return
+	}
+
+	// remove data, uncompressed and archived
+	conf := config.GetConfig()
+	err := os.RemoveAll(fmt.Sprintf("%s%d/", conf.RecordPath, recording.Id))
+	if err != nil {
+		log.Printf("❌ Error deleting uncompressed data: %v", err)
+	}
+	err = os.Remove(fmt.Sprintf("%s%d.tar.gz", conf.RecordPath, recording.Id))
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("❌ Error deleting archive: %v", err)
+	}
+
+	recording.Delete()
+	notify(recording.Id)
+
+	log.Printf("🗑️ "+color.Blue+" Deleted %v\n"+color.Reset, recording.Id)
+	writeJSON(writer, http.StatusOK, recording)
+}
+
+// POST "/recordings/id/cancel" cancels a queued or running recording, a
+// running one keeps the data captured so far
+func CancelRecording(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	recording := getRecording(writer, request)
+	if recording == nil {
+		recordingsMu.Unlock()
+		return
+	}
+
+	switch recording.Status {
+	case models.Created:
+		recording.Status = models.Cancelled
+		recording.Error = "Cancelled before starting"
+		recording.Update()
+		recordingsMu.Unlock()
+		notify(recording.Id)
+	case models.Running:
+		done := cancelRunning(recording.Id)
+		recordingsMu.Unlock()
+		if done == nil {
+			writeError(writer, http.StatusConflict, "Recording is not running yet, try again")
+			return
+		}
+		// wait for it to stop and archive the data
+		select {
+		case <-done:
+		case <-time.After(cancelTimeout):
+			writeError(writer, http.StatusAccepted, "Cancel requested, still stopping")
+			return
+		}
+		recording, _ = models.GetRecordingById(recording.Id)
+	default:
+		recordingsMu.Unlock()
+		writeError(writer, http.StatusConflict, fmt.Sprintf("Recording already %s", recording.Status))
+		return
+	}
+
+	log.Printf("🛑"+color.Blue+" Cancelled %v\n"+color.Reset, recording.Id)
+	writeJSON(writer, http.StatusOK, recording)
+}
+
+// PATCH "/recordings/id" applies a partial update to a recording that has
+// not started yet
+func PatchRecording(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	recording := getRecording(writer, request)
+	if recording == nil {
+		return
+	}
+	if recording.Status != models.Created {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("Recording already %s", recording.Status))
+		return
+	}
+
+	// apply the fields present in the JSON over a copy
+	patched := *recording
+	err := utils.ParseBody(request, &patched)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	// these can't be changed
+	patched.Model = recording.Model
+	patched.Id = recording.Id
+	patched.Status = recording.Status
+	patched.Error = recording.Error
+	patched.SeriesId = recording.SeriesId
+	patched.SurveyId = recording.SurveyId
+	patched.Attempts = recording.Attempts
+	patched.OriginalTime = recording.OriginalTime
+	patched.ScanRaStart = recording.ScanRaStart
+	patched.ScanRaEnd = recording.ScanRaEnd
+	patched.ScanDec = recording.ScanDec
+
+	// check fields
+	err = patched.Check()
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	// check it still fits in the schedule
+	if !checkConflicts(writer, config.GetConfig(), &patched) {
+		return
+	}
+	if !checkPointing(writer, config.GetConfig(), &patched) {
+		return
+	}
+	// points captured before a preemption or a retry would be mixed with
+	// data taken differently, it starts over
+	if !patched.SameCapture(recording) && len(recording.Progress()) > 0 {
+		log.Printf("⚠️ "+color.Yellow+" Capture of %v changed, discarding the points already captured\n"+color.Reset, patched.Id)
+		err := os.RemoveAll(fmt.Sprintf("%s%d/", config.GetConfig().RecordPath, patched.Id))
+		if err != nil {
+			log.Printf("❌ Error deleting uncompressed data: %v", err)
+		}
+		patched.ClearProgress()
+		patched.ClearTrajectory()
+	}
+	patched.Update()
+
+	// send notification
+	notify(patched.Id)
+
+	log.Printf("📝"+color.Blue+" Updated %v\n"+color.Reset, patched.Id)
+	writeJSON(writer, http.StatusOK, &patched)
+}
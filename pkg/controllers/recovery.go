@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"fmt"
+	"log"
+	"os"
+)
+
+// what to do with recordings interrupted by a crash (recovery_policy in
+// config.toml)
+const (
+	RecoverFail    = "fail"
+	RecoverArchive = "archive"
+	RecoverRequeue = "requeue"
+)
+
+// finds the recordings left Running by a previous run of the program and
+// fails, archives or re-queues them according to the recovery policy
+// to be called on startup before the scheduler
+func Recover() {
+	conf := config.GetConfig()
+
+	for _, rec := range models.GetRecordingsByStatus(models.Running) {
+		grid := rec.Grid()
+		done := len(completedPoints(conf, &rec))
+		log.Printf("🩹"+color.Yellow+" Recovering %v, %d of %d points captured\n"+color.Reset, rec.Id, done, len(grid))
+
+		switch conf.RecoveryPolicy {
+		case RecoverRequeue:
+			rec.Status = models.Created
+			rec.Error = ""
+		case RecoverArchive:
+			if done == 0 {
+				rec.Status = models.Failed
+				rec.Error = "Interrupted before capturing any point"
+				break
+			}
+			if err := archive(conf, rec.Id); err != nil {
+				rec.Status = models.Failed
+				rec.Error = err.Error()
+				break
+			}
+			rec.Status = models.Partial
+			rec.Error = fmt.Sprintf("Interrupted, %d of %d points captured", done, len(grid))
+		default:
+			rec.Status = models.Failed
+			rec.Error = fmt.Sprintf("Interrupted, %d of %d points captured", done, len(grid))
+		}
+		rec.Update()
+		log.Printf("🩹"+color.Yellow+" Recording %v is now %s\n"+color.Reset, rec.Id, rec.Status)
+	}
+}
+
+// grid points fully captured according to the saved progress, data files
+// that are missing from it were cut by the crash or a cancel and are removed
+func completedPoints(conf config.Config, rec *models.Recording) map[int]models.PointProgress {
+	dirname := fmt.Sprintf("%s%d/", conf.RecordPath, rec.Id)
+	grid := rec.Grid()
+	completed := map[int]models.PointProgress{}
+	files := map[string]bool{}
+	for _, progress := range rec.Progress() {
+		// the grid may have changed since
+		if progress.Point >= len(grid) || progress.File != rec.DataFile(grid[progress.Point]) {
+			continue
+		}
+		info, err := os.Stat(dirname + progress.File)
+		if err != nil || info.Size() != progress.Bytes {
+			continue
+		}
+		completed[progress.Point] = progress
+		files[progress.File] = true
+		files[progress.File+markerSuffix] = true
+	}
+
+	entries, err := os.ReadDir(dirname)
+	if err != nil {
+		return completed
+	}
+	for _, entry := range entries {
+		if !files[entry.Name()] {
+			log.Printf("🗑️  Removing incomplete %s\n", entry.Name())
+			if err := os.Remove(dirname + entry.Name()); err != nil {
+				log.Printf("❌ Error removing incomplete data file: %v", err)
+			}
+		}
+	}
+	return completed
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// what to do with recordings overlapping others (schedule_conflicts in
+// config.toml)
+const (
+	ConflictsReject = "reject"
+	ConflictsFlag   = "flag"
+)
+
+// what to do with recordings falling in a blackout window (blackout_policy
+// in config.toml)
+const (
+	BlackoutReject     = "reject"
+	BlackoutReschedule = "reschedule"
+)
+
+// a period of time, in milliseconds, the station is busy with a recording
+type reservation struct {
+	Id       int64
+	Start    int64
+	End      int64
+	Priority int
+	Running  bool
+}
+
+// estimates how long a recording takes, including moving the rotor between
+// the points of the grid, and stores it in CalcTime (milliseconds)
+func estimate(conf config.Config, rec *models.Recording) {
+	rec.EstimateTime(makePlan(conf, rec).Slew)
+}
+
+// reservations of the queued and running recordings sorted by start,
+// except the one with the exclude id
+func reservations(conf config.Config, exclude int64) []reservation {
+	var res []reservation
+	for _, status := range []models.RecordStatus{models.Created, models.Running} {
+		for _, rec := range models.GetRecordingsByStatus(status) {
+			if rec.Id == exclude {
+				continue
+			}
+			if rec.CalcTime <= 0 {
+				estimate(conf, &rec)
+			}
+			res = append(res, reservation{
+				Id:       rec.Id,
+				Start:    rec.Time,
+				End:      rec.Time + rec.CalcTime,
+				Priority: rec.Priority,
+				Running:  status == models.Running,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Start < res[j].Start
+	})
+	return res
+}
+
+// the blackout windows not over yet as reservations, sorted by start
+func blackouts() []reservation {
+	var res []reservation
+	for _, b := range models.GetBlackoutsAfter(time.Now().UnixMilli()) {
+		res = append(res, reservation{Id: b.Id, Start: b.Start, End: b.End})
+	}
+	return res
+}
+
+// reservations of both lists sorted by start
+func merge(a []reservation, b []reservation) []reservation {
+	res := append(append([]reservation{}, a...), b...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Start < res[j].Start
+	})
+	return res
+}
+
+// the reservations a recording has to make room for: the queued ones with
+// the same or a higher priority, and the running one unless the recording
+// can preempt it
+func blocking(res []reservation, rec *models.Recording) []reservation {
+	var blocked []reservation
+	for _, r := range res {
+		if r.Priority < rec.Priority && (!r.Running || rec.Preempt) {
+			continue
+		}
+		blocked = append(blocked, r)
+	}
+	return blocked
+}
+
+// ids of the reservations overlapping a period of time
+func conflicts(res []reservation, start int64, end int64) []int64 {
+	var ids []int64
+	for _, r := range res {
+		if start < r.End && r.Start < end {
+			ids = append(ids, r.Id)
+		}
+	}
+	return ids
+}
+
+// earliest start, not before the given one, of a free period of time
+func nextFreeSlot(res []reservation, start int64, duration int64) int64 {
+	for _, r := range res {
+		if start+duration <= r.Start {
+			break
+		}
+		if start < r.End {
+			start = r.End
+		}
+	}
+	return start
+}
+
+// moves a recording falling in blackout windows after them, and after the
+// reservations, if the policy says so, otherwise returns the windows and
+// the next free slot for it to be rejected
+func avoidBlackouts(conf config.Config, rec *models.Recording, res []reservation) ([]int64, int64) {
+	closed := blackouts()
+	ids := conflicts(closed, rec.Time, rec.Time+rec.CalcTime)
+	if len(ids) == 0 {
+		return nil, 0
+	}
+	slot := nextFreeSlot(merge(res, closed), rec.Time, rec.CalcTime)
+	if conf.BlackoutPolicy != BlackoutReschedule {
+		return ids, slot
+	}
+	log.Printf("🚧"+color.Yellow+" Blackout %v, moving recording to %v\n"+color.Reset, ids, slot)
+	rec.Time = slot
+	return nil, 0
+}
+
+// checks the time of a recording against the blackout windows and the
+// other recordings, estimating its duration first, moves it after the
+// blackouts if the policy says so, returns false and writes the error
+// response if it has to be rejected
+func checkConflicts(writer http.ResponseWriter, conf config.Config, rec *models.Recording) bool {
+	estimate(conf, rec)
+	res := blocking(reservations(conf, rec.Id), rec)
+
+	if ids, slot := avoidBlackouts(conf, rec, res); len(ids) > 0 {
+		writeJSON(writer, http.StatusConflict, struct {
+			Error        string  `json:"error"`
+			Blackouts    []int64 `json:"blackouts"`
+			NextFreeSlot int64   `json:"next_free_slot"`
+		}{
+			Error:        "Station unavailable, blackout window",
+			Blackouts:    ids,
+			NextFreeSlot: slot,
+		})
+		return false
+	}
+
+	rec.Conflicts = conflicts(res, rec.Time, rec.Time+rec.CalcTime)
+	if len(rec.Conflicts) == 0 || conf.ScheduleConflicts == ConflictsFlag {
+		return true
+	}
+
+	writeJSON(writer, http.StatusConflict, struct {
+		Error        string  `json:"error"`
+		Conflicts    []int64 `json:"conflicts"`
+		NextFreeSlot int64   `json:"next_free_slot"`
+	}{
+		Error:        "Time already reserved by other recordings",
+		Conflicts:    rec.Conflicts,
+		NextFreeSlot: nextFreeSlot(res, rec.Time, rec.CalcTime),
+	})
+	return false
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"carlosapi/pkg/models"
+	"slices"
+	"testing"
+)
+
+func TestNextFreeSlot(t *testing.T) {
+	res := []reservation{
+		{Id: 1, Start: 100, End: 200},
+		{Id: 2, Start: 200, End: 300},
+		{Id: 3, Start: 400, End: 500},
+		// inside the one before
+		{Id: 4, Start: 420, End: 450},
+		{Id: 5, Start: 700, End: 800},
+	}
+	tests := []struct {
+		name     string
+		start    int64
+		duration int64
+		want     int64
+	}{
+		{"before all", 0, 100, 0},
+		{"too long before all", 50, 100, 300},
+		{"in the first", 150, 10, 300},
+		// back to back reservations leave no room between them
+		{"at the end of one", 200, 50, 300},
+		{"gap that fits exactly", 300, 100, 300},
+		{"gap too short", 300, 101, 500},
+		{"in one containing another", 430, 100, 500},
+		{"after the nested one", 500, 200, 500},
+		{"after all", 900, 1000, 900},
+		{"only after all", 500, 300, 800},
+	}
+	for _, test := range tests {
+		if got := nextFreeSlot(res, test.start, test.duration); got != test.want {
+			t.Errorf("%s: nextFreeSlot(%d, %d) = %d, want %d", test.name, test.start, test.duration, got, test.want)
+		}
+	}
+	if got := nextFreeSlot(nil, 42, 1000); got != 42 {
+		t.Errorf("nextFreeSlot with no reservations = %d, want 42", got)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	res := []reservation{
+		{Id: 1, Start: 100, End: 200},
+		{Id: 2, Start: 150, End: 250},
+		{Id: 3, Start: 300, End: 400},
+	}
+	tests := []struct {
+		start int64
+		end   int64
+		want  []int64
+	}{
+		{0, 100, nil},
+		{0, 101, []int64{1}},
+		{160, 170, []int64{1, 2}},
+		{200, 300, []int64{2}},
+		{250, 300, nil},
+		{0, 1000, []int64{1, 2, 3}},
+	}
+	for _, test := range tests {
+		if got := conflicts(res, test.start, test.end); !slices.Equal(got, test.want) {
+			t.Errorf("conflicts(%d, %d) = %v, want %v", test.start, test.end, got, test.want)
+		}
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	res := []reservation{
+		{Id: 1, Priority: 0},
+		{Id: 2, Priority: 1},
+		{Id: 3, Priority: 2},
+		{Id: 4, Priority: 0, Running: true},
+		{Id: 5, Priority: 2, Running: true},
+	}
+	tests := []struct {
+		name     string
+		priority int
+		preempt  bool
+		want     []int64
+	}{
+		{"lowest", 0, false, []int64{1, 2, 3, 4, 5}},
+		// the running one stays unless it can be preempted
+		{"higher", 1, false, []int64{2, 3, 4, 5}},
+		{"higher preempting", 1, true, []int64{2, 3, 5}},
+		// not the running one with the same priority
+		{"highest preempting", 2, true, []int64{3, 5}},
+		{"above all preempting", 3, true, nil},
+	}
+	for _, test := range tests {
+		rec := &models.Recording{Priority: test.priority, Preempt: test.preempt}
+		var got []int64
+		for _, r := range blocking(res, rec) {
+			got = append(got, r.Id)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: blocking = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := []reservation{{Id: 1, Start: 100}, {Id: 3, Start: 300}}
+	b := []reservation{{Id: 2, Start: 200}, {Id: 4, Start: 400}}
+	var got []int64
+	for _, r := range merge(a, b) {
+		got = append(got, r.Id)
+	}
+	if want := []int64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("merge = %v, want %v", got, want)
+	}
+	// the lists given are left as they were
+	if a[1].Id != 3 || b[0].Id != 2 || len(a) != 2 {
+		t.Errorf("merge changed its arguments: %v %v", a, b)
+	}
+}
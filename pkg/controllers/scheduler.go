@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/scheduler"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// notifications to the scheduler, buffered so handlers don't wait for it
+var updateChannel chan models.Notification
+
+// set when a notification didn't fit in the channel, the scheduler reloads
+// the whole queue from the database
+var resync atomic.Bool
+
+// recordings waiting to run
+var queue *scheduler.Queue
+
+// serializes status changes of recordings between the HTTP handlers and
+// the scheduler
+var recordingsMu sync.Mutex
+
+// set when the program is stopping, no more recordings are accepted or
+// launched
+var draining atomic.Bool
+
+func init() {
+	updateChannel = make(chan models.Notification, 64)
+	queue = scheduler.NewQueue()
+}
+
+func GetChannel() chan models.Notification {
+	return updateChannel
+}
+
+// tells the scheduler that a recording changed, never blocks
+func notify(id int64) {
+	select {
+	case updateChannel <- models.Notification{Id: id}:
+	default:
+		resync.Store(true)
+	}
+}
+
+// "/queue" returns the recordings waiting to run by start time, when several
+// are due the one with the highest priority runs first
+func GetQueue(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, queue.Snapshot())
+}
+
+// Scheduler, sleeps until the next recording is due and launches it, wakes
+// up early when notified of changes, keeps an occurrence of each series
+// queued
+// launched on another thread
+func RunScheduling() {
+	log.Println("⏰" + color.Yellow + " Starting Scheduler" + color.Reset)
+
+	queue.Reset(models.GetRecordingsByStatus(models.Created))
+
+	timer := time.NewTimer(0)
+	for {
+		select {
+		case n := <-updateChannel:
+			update(n)
+		case <-timer.C:
+		}
+		if resync.Swap(false) {
+			queue.Reset(models.GetRecordingsByStatus(models.Created))
+		}
+
+		launchDue()
+		spawnSeries()
+
+		// sleep until the next one is due, or until notified if there is
+		// nothing to do or something is running
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wake, ok := nextWake(); ok {
+			timer.Reset(time.Until(wake))
+		}
+	}
+}
+
+// applies a notification to the queue
+func update(n models.Notification) {
+	if n.Id == 0 {
+		resync.Store(true)
+		return
+	}
+	rec, result := models.GetRecordingById(n.Id)
+	if result.Error == nil && rec.Status == models.Created {
+		queue.Push(*rec)
+	} else {
+		queue.Remove(n.Id)
+	}
+}
+
+// the due recording with the highest priority, the earliest one among
+// equals
+func nextDue(now int64) (models.Recording, bool) {
+	var next models.Recording
+	found := false
+	for _, rec := range queue.Snapshot() {
+		if rec.Time > now {
+			break
+		}
+		if !found || rec.Priority > next.Priority {
+			next = rec
+			found = true
+		}
+	}
+	return next, found
+}
+
+// the blackout window the station is in, if any
+func activeBlackout(now int64) (models.Blackout, bool) {
+	for _, b := range models.GetBlackoutsAfter(now) {
+		if b.Start <= now {
+			return b, true
+		}
+	}
+	return models.Blackout{}, false
+}
+
+// when the scheduler has to wake up: when the next recording is due, or
+// while something is running when the next one that may preempt it is due,
+// and when a blackout window starts or ends. Recordings already due can't
+// launch in a blackout, it waits for it to end, and nothing launches while
+// stopping
+func nextWake() (time.Time, bool) {
+	if draining.Load() {
+		return time.Time{}, false
+	}
+	now := time.Now().UnixMilli()
+	recording := config.IsRecording()
+	_, closed := activeBlackout(now)
+	var wake int64
+	for _, rec := range queue.Snapshot() {
+		if recording && !rec.Preempt {
+			continue
+		}
+		if (recording || closed) && rec.Time <= now {
+			continue
+		}
+		wake = rec.Time
+		break
+	}
+	for _, b := range models.GetBlackoutsAfter(now) {
+		edge := b.End
+		if b.Start > now {
+			edge = b.Start
+		}
+		if wake == 0 || edge < wake {
+			wake = edge
+		}
+	}
+	if wake == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(wake), true
+}
+
+// launches the next recording if it is due and nothing else is running, or
+// asks the running one to make room for it if it can preempt it or if a
+// blackout window started
+func launchDue() {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if draining.Load() {
+		return
+	}
+
+	// nothing runs in a blackout, the running recording stops at its next
+	// point and continues afterwards
+	now := time.Now().UnixMilli()
+	if b, ok := activeBlackout(now); ok {
+		if config.IsRecording() && pauseRunning() {
+			log.Printf("🚧"+color.Yellow+" Blackout %v, pausing\n"+color.Reset, b.Id)
+		}
+		return
+	}
+
+	next, ok := nextDue(now)
+	if !ok {
+		return
+	}
+	if config.IsRecording() {
+		if next.Preempt && preemptRunning(next.Priority) {
+			log.Printf("⏸️ "+color.Yellow+" Preempting for %v\n"+color.Reset, next.Id)
+		}
+		return
+	}
+	queue.Remove(next.Id)
+
+	// make sure it didn't change behind our back
+	rec, result := models.GetRecordingById(next.Id)
+	if result.Error != nil || rec.Status != models.Created {
+		return
+	}
+
+	log.Printf("⚡"+color.Yellow+" Launching %v\n"+color.Reset, rec.Id)
+	rec.Status = models.Running
+	// retries may slip from here
+	if rec.OriginalTime == 0 {
+		rec.OriginalTime = rec.Time
+	}
+	rec.Update()
+	config.Recording()
+	go RunProcess(startRunning(rec), *rec)
+}
+
+// stops accepting and launching recordings, lets the running one finish
+// its current point, or interrupts it after the timeout, and puts it back
+// in the queue to continue on the next start
+func Shutdown(timeout time.Duration) {
+	// nothing launches once draining is set, so what runs now is all there
+	// is to wait for
+	recordingsMu.Lock()
+	draining.Store(true)
+	cancel, done := checkpointRunning()
+	recordingsMu.Unlock()
+	awaitCheckpoint(timeout, cancel, done)
+}
+
+// writes the error response and returns false if the program is stopping
+func acceptingJobs(writer http.ResponseWriter) bool {
+	if draining.Load() {
+		writeError(writer, http.StatusServiceUnavailable, "Shutting down, not accepting recordings")
+		return false
+	}
+	return true
+}
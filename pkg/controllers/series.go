@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// most occurrences of a series skipped in a row looking for one that can
+// be spawned, the rest wait for the next time the scheduler wakes up
+const maxSkips = 100
+
+// spawns the next occurrence of a series unless it is paused, over or
+// already has one waiting, returns its id or 0. Occurrences are checked
+// like new recordings, those falling in blackouts are moved after them if
+// the policy says so, and those that would be rejected are skipped
+// to be called with recordingsMu held
+func spawn(series *models.Series) int64 {
+	if series.Paused {
+		return 0
+	}
+	series.CatchUp()
+	if _, ok := series.Pending(); ok {
+		return 0
+	}
+
+	conf := config.GetConfig()
+	var rec models.Recording
+	for skips := 0; ; skips++ {
+		if series.Over() || skips == maxSkips {
+			series.Update()
+			return 0
+		}
+		rec = series.Occurrence()
+		reason := placeOccurrence(conf, &rec)
+		if reason == "" {
+			break
+		}
+		log.Printf("⚠️ "+color.Yellow+" Series %v skips the occurrence at %v: %s\n"+color.Reset, series.Id, rec.Time, reason)
+		series.Skip()
+	}
+	rec.Id = recordingIds.next()
+	rec.CreateRecording()
+	series.Advance()
+	series.Update()
+
+	log.Printf("🔁"+color.Blue+" Series %v added %v\n"+color.Reset, series.Id, rec.Id)
+	return rec.Id
+}
+
+// checks an occurrence of a series like a new recording, moving it after
+// the blackouts if the policy says so, returns why it has to be skipped or
+// ""
+func placeOccurrence(conf config.Config, rec *models.Recording) string {
+	estimate(conf, rec)
+	res := blocking(reservations(conf, 0), rec)
+	if ids, _ := avoidBlackouts(conf, rec, res); len(ids) > 0 {
+		return fmt.Sprintf("Station unavailable, blackout window %v", ids)
+	}
+	if violations := rejectedPoints(conf, rec); len(violations) > 0 {
+		return violations[0].Violation
+	}
+	// the queue runs them one after the other anyway
+	if ids := conflicts(res, rec.Time, rec.Time+rec.CalcTime); len(ids) > 0 {
+		log.Printf("⚠️ "+color.Yellow+" Occurrence overlaps %v\n"+color.Reset, ids)
+	}
+	return ""
+}
+
+// spawns the next occurrence of every series that needs one
+func spawnSeries() {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if draining.Load() {
+		return
+	}
+	for _, series := range models.GetSeries() {
+		if id := spawn(&series); id != 0 {
+			notify(id)
+		}
+	}
+}
+
+// gets the series identified by the "id" in the URL, writes the error
+// response and returns nil if it can't
+func getSeries(writer http.ResponseWriter, request *http.Request) *models.Series {
+	vars := mux.Vars(request)
+	id, err := strconv.ParseInt(vars["id"], 0, 0)
+	if err != nil {
+		log.Printf("❌ ID Parse Error %v\n", err.Error())
+		writeError(writer, http.StatusBadRequest, "Problem parsing ID")
+		return nil
+	}
+	series, result := models.GetSeriesById(id)
+	if result.Error != nil {
+		writeError(writer, http.StatusNotFound, "No series with that ID")
+		return nil
+	}
+	return series
+}
+
+// removes the occurrence of a series waiting to run so it can be spawned
+// again later
+// to be called with recordingsMu held
+func unspawn(series *models.Series) {
+	rec, ok := series.Pending()
+	if !ok {
+		return
+	}
+	rec.Delete()
+	notify(rec.Id)
+	series.Occurrences--
+	series.Next = rec.Time
+}
+
+// POST "/series" creates a recurring recording, takes the same JSON as
+// "/record" without the time plus the repetition: "cron" or "sidereal" from
+// "start", "until", "max_occurrences"
+func CreateSeries(writer http.ResponseWriter, request *http.Request) {
+	if !acceptingJobs(writer) {
+		return
+	}
+	series := &models.Series{}
+	err := utils.ParseBody(request, series)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	series.Occurrences = 0
+	err = series.Check()
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	series.Id = seriesIds.next()
+	series.Create()
+	if id := spawn(series); id != 0 {
+		notify(id)
+	}
+
+	log.Printf("🔁"+color.Blue+" Added series %v\n"+color.Reset, series.Id)
+	writeJSON(writer, http.StatusOK, series)
+}
+
+// GET "/series" returns all the series
+func GetAllSeries(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, models.GetSeries())
+}
+
+// GET "/series/id" returns a series
+func GetSeriesId(writer http.ResponseWriter, request *http.Request) {
+	series := getSeries(writer, request)
+	if series == nil {
+		return
+	}
+	writeJSON(writer, http.StatusOK, series)
+}
+
+// POST "/series/id/pause" stops spawning occurrences, the one waiting to
+// run is removed
+func PauseSeries(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	series := getSeries(writer, request)
+	if series == nil {
+		return
+	}
+	if !series.Paused {
+		unspawn(series)
+		series.Paused = true
+		series.Update()
+		log.Printf("⏸️ "+color.Blue+" Paused series %v\n"+color.Reset, series.Id)
+	}
+	writeJSON(writer, http.StatusOK, series)
+}
+
+// POST "/series/id/resume" spawns occurrences again from now on
+func ResumeSeries(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	series := getSeries(writer, request)
+	if series == nil {
+		return
+	}
+	if series.Paused {
+		series.Paused = false
+		series.Update()
+		if id := spawn(series); id != 0 {
+			notify(id)
+		}
+		log.Printf("▶️ "+color.Blue+" Resumed series %v\n"+color.Reset, series.Id)
+	}
+	writeJSON(writer, http.StatusOK, series)
+}
+
+// DELETE "/series/id" removes a series and its occurrence waiting to run,
+// the recordings already made stay
+func DeleteSeries(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	series := getSeries(writer, request)
+	if series == nil {
+		return
+	}
+	unspawn(series)
+	series.Delete()
+
+	log.Printf("🗑️ "+color.Blue+" Deleted series %v\n"+color.Reset, series.Id)
+	writeJSON(writer, http.StatusOK, series)
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"carlosapi/pkg/color"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"carlosapi/pkg/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// schedules the recording of a longitude of a survey centered on its
+// transit, or as close after it as the queue and the blackouts allow,
+// returns the point with the recording or why it was left out
+// to be called with recordingsMu held
+func schedulePoint(conf config.Config, survey *models.Survey, l float64, from time.Time) models.SurveyPoint {
+	point := models.SurveyPoint{SurveyId: survey.Id, L: l}
+	transit, maxEl, up := models.Transit(l, 0, from, conf.Constraints.MinElevation)
+	point.Transit, point.MaxEl = transit.UnixMilli(), maxEl
+	if maxEl <= 0 {
+		point.Skipped = "Never above the horizon"
+		return point
+	}
+
+	rec := survey.Recording(l)
+	rec.Time = point.Transit
+	estimate(conf, &rec)
+	start := max(point.Transit-rec.CalcTime/2, from.UnixMilli())
+	rec.Time = nextFreeSlot(merge(blocking(reservations(conf, 0), &rec), blackouts()), start, rec.CalcTime)
+	// the middle of the recording no further from the transit than half
+	// the time it is above the minimum elevation
+	if rec.Time+rec.CalcTime/2-point.Transit > up.Milliseconds() {
+		point.Skipped = "No free slot near transit"
+		return point
+	}
+	plan := makePlan(conf, &rec)
+	if violations := plan.violations(); len(violations) > 0 {
+		point.Skipped = violations[0].Violation
+		return point
+	}
+
+	rec.Id = recordingIds.next()
+	rec.CreateRecording()
+	point.RecordingId = rec.Id
+	return point
+}
+
+// gets the survey identified by the "id" in the URL, writes the error
+// response and returns nil if it can't
+func getSurvey(writer http.ResponseWriter, request *http.Request) *models.Survey {
+	vars := mux.Vars(request)
+	id, err := strconv.ParseInt(vars["id"], 0, 0)
+	if err != nil {
+		log.Printf("❌ ID Parse Error %v\n", err.Error())
+		writeError(writer, http.StatusBadRequest, "Problem parsing ID")
+		return nil
+	}
+	survey, result := models.GetSurveyById(id)
+	if result.Error != nil {
+		writeError(writer, http.StatusNotFound, "No survey with that ID")
+		return nil
+	}
+	survey.LoadPoints()
+	return survey
+}
+
+// POST "/surveys" maps the galactic plane, takes the receiver settings of
+// "/record" plus "l_from", "l_to", "l_step" and "start", adds one recording
+// per longitude around its transit
+func CreateSurvey(writer http.ResponseWriter, request *http.Request) {
+	if !acceptingJobs(writer) {
+		return
+	}
+	survey := &models.Survey{}
+	err := utils.ParseBody(request, survey)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	err = survey.Check()
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conf := config.GetConfig()
+	from := time.Now().Add(time.Second)
+	if start := time.UnixMilli(survey.Start); start.After(from) {
+		from = start
+	}
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	survey.Id = surveyIds.next()
+	survey.Create()
+	for _, l := range survey.Longitudes() {
+		point := schedulePoint(conf, survey, l, from)
+		point.Create()
+		if point.RecordingId != 0 {
+			notify(point.RecordingId)
+		} else {
+			log.Printf("⚠️ "+color.Yellow+" Survey %v leaves out l=%.1f: %v\n"+color.Reset, survey.Id, l, point.Skipped)
+		}
+	}
+	survey.LoadPoints()
+
+	log.Printf("🌌"+color.Blue+" Added survey %v\n"+color.Reset, survey.Id)
+	writeJSON(writer, http.StatusOK, survey)
+}
+
+// GET "/surveys" returns all the surveys
+func GetSurveys(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, models.GetSurveys())
+}
+
+// GET "/surveys/id" returns a survey with its points and the status of
+// their recordings
+func GetSurveyId(writer http.ResponseWriter, request *http.Request) {
+	survey := getSurvey(writer, request)
+	if survey == nil {
+		return
+	}
+	writeJSON(writer, http.StatusOK, survey)
+}
+
+// DELETE "/surveys/id" removes a survey and its recordings waiting to run,
+// the ones already made stay
+func DeleteSurvey(writer http.ResponseWriter, request *http.Request) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	survey := getSurvey(writer, request)
+	if survey == nil {
+		return
+	}
+	for _, point := range survey.Points {
+		if point.Status != string(models.Created) {
+			continue
+		}
+		if rec, result := models.GetRecordingById(point.RecordingId); result.Error == nil {
+			rec.Delete()
+			notify(rec.Id)
+		}
+	}
+	survey.Delete()
+
+	log.Printf("🗑️ "+color.Blue+" Deleted survey %v\n"+color.Reset, survey.Id)
+	writeJSON(writer, http.StatusOK, survey)
+}
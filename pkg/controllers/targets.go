@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"carlosapi/pkg/astro"
+	"carlosapi/pkg/catalog"
+	"carlosapi/pkg/config"
+	"carlosapi/pkg/models"
+	"net/http"
+	"time"
+)
+
+// a target of the catalog as seen from the station now, degrees and times
+// in milliseconds, rise and set through the minimum elevation of the
+// constraints, 0 if they don't happen in the next day
+type TargetInfo struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Ra         float64 `json:"ra"`
+	Dec        float64 `json:"dec"`
+	Az         float64 `json:"az"`
+	El         float64 `json:"el"`
+	Rise       int64   `json:"rise,omitempty"`
+	Set        int64   `json:"set,omitempty"`
+	Transit    int64   `json:"transit,omitempty"`
+	Observable bool    `json:"observable"`
+	// why it can't be observed now
+	Reason string `json:"reason,omitempty"`
+}
+
+// milliseconds of a time, 0 for the zero time
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// "/targets" returns the targets of the catalog, where they are now and
+// whether they can be observed, to be used as "target" in "/record"
+func GetTargets(writer http.ResponseWriter, request *http.Request) {
+	conf := config.GetConfig()
+	station := astro.NewObserver(conf.Station)
+	now := time.Now()
+
+	infos := []TargetInfo{}
+	for _, target := range catalog.All() {
+		info := TargetInfo{Name: target.Name, Kind: target.Kind}
+		info.Ra, info.Dec = target.Equatorial(now)
+		info.Az, info.El = target.Horizontal(station, now)
+		rise, set, transit := target.Events(station, now, conf.Constraints.MinElevation)
+		info.Rise, info.Set, info.Transit = millis(rise), millis(set), millis(transit)
+		if err := checkSky(conf, models.Pointing{Az: float32(info.Az), El: float32(info.El)}, now, target.Kind == catalog.KindSun); err != nil {
+			info.Reason = err.Error()
+		} else {
+			info.Observable = true
+		}
+		infos = append(infos, info)
+	}
+
+	writeJSON(writer, http.StatusOK, infos)
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"carlosapi/pkg/astro"
+	"carlosapi/pkg/config"
+	"net/http"
+	"time"
+)
+
+// times at the station, sidereal times in hours
+type StationTime struct {
+	UTC        time.Time `json:"utc"`
+	Local      time.Time `json:"local"`
+	Timezone   string    `json:"timezone"`
+	JulianDate float64   `json:"julian_date"`
+	GMST       float64   `json:"gmst"`
+	LST        float64   `json:"lst"`
+}
+
+// "/time" returns the current time at the station in the forms the sky
+// needs
+func GetTime(writer http.ResponseWriter, request *http.Request) {
+	station := astro.NewObserver(config.GetConfig().Station)
+	now := time.Now().UTC()
+	writeJSON(writer, http.StatusOK, StationTime{
+		UTC:        now,
+		Local:      now.In(station.Location),
+		Timezone:   station.Location.String(),
+		JulianDate: astro.JulianDate(now),
+		GMST:       astro.GMST(now) / 15,
+		LST:        station.LST(now) / 15,
+	})
+}
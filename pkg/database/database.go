@@ -19,3 +19,12 @@ func ConnectDB(filename string) {
 func GetDB() *gorm.DB {
 	return db
 }
+
+// closes the database, flushing what is pending
+func CloseDB() error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
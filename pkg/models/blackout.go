@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// a period of time the station must not observe, times in milliseconds
+type Blackout struct {
+	gorm.Model
+	Id			int64	`json:"id"`
+	Start		int64	`json:"start"`
+	End			int64	`json:"end"`
+	Reason		string	`json:"reason"`
+}
+
+// add a blackout to the database
+func (b *Blackout) Create() *Blackout {
+	db.Create(&b)
+	return b
+}
+
+// delete a blackout
+func (b *Blackout) Delete() {
+	db.Where("id=?", b.Id).Delete(&Blackout{})
+}
+
+// check blackout fields
+func (b *Blackout) Check() error {
+	if b.End <= b.Start {
+		return fmt.Errorf("End has to be after start")
+	}
+	return nil
+}
+
+// Get all blackouts sorted by start
+func GetBlackouts() []Blackout {
+	var blackouts []Blackout
+	db.Order("start").Find(&blackouts)
+	return blackouts
+}
+
+// Get the blackouts not over at a time, sorted by start
+func GetBlackoutsAfter(t int64) []Blackout {
+	var blackouts []Blackout
+	db.Where("`end` > ?", t).Order("start").Find(&blackouts)
+	return blackouts
+}
+
+// Get a blackout by it's ID
+func GetBlackoutById(Id int64) (*Blackout, *gorm.DB) {
+	var blackout Blackout
+	result := db.Where("id=?", Id).First(&blackout)
+	return &blackout, result
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// how an attempt ended
+const(
+	AttemptRetrying = "Retrying"
+	AttemptPaused = "Paused"
+	AttemptSkipped = "Skipped"
+)
+
+// a run of a recording, or a failed try at one of its points, times in
+// milliseconds
+type Attempt struct {
+	gorm.Model
+	RecordingId	int64	`json:"recording_id"`
+	// grid point, -1 for the whole run
+	Point		int		`json:"point"`
+	Start		int64	`json:"start"`
+	End			int64	`json:"end"`
+	Result		string	`json:"result"`
+	Error		string	`json:"error"`
+}
+
+// add an attempt to the history of its recording
+func (a *Attempt) Create() *Attempt {
+	db.Create(&a)
+	return a
+}
+
+// loads the attempts of a recording in the order they were made
+func (r *Recording) LoadHistory() {
+	r.History = nil
+	db.Where("recording_id=?", r.Id).Order("id").Find(&r.History)
+}
+
+// forget the attempts of a recording
+func (r *Recording) ClearHistory() {
+	db.Where("recording_id=?", r.Id).Delete(&Attempt{})
+}
+
+// how long to wait before the given retry (1 for the first one), the
+// backoff doubles each time up to an hour
+func (r *Recording) RetryDelay(retry int) time.Duration {
+	delay := time.Duration(r.Backoff) * time.Millisecond
+	for i := 1; i < retry && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
@@ -1,10 +1,14 @@
 package models
 
 import(
+	"carlosapi/pkg/astro"
+	"carlosapi/pkg/catalog"
 	"carlosapi/pkg/database"
 	"carlosapi/pkg/config"
+	"carlosapi/pkg/rotator"
 	"fmt"
 	"gorm.io/gorm"
+	"math"
 	"time"
 )
 
@@ -13,10 +17,39 @@ const(
 	Created = "Created"
 	Running = "Running"
 	Finished = "Finished"
+	Failed = "Failed"
+	Cancelled = "Cancelled"
+	Partial = "Partial"
+)
+
+// how a recording observes: stepping through its grid, or parked while
+// the sky drifts through the beam in one continuous capture
+const(
+	ModeGrid = "grid"
+	ModeDrift = "drift"
+)
+
+// coordinates a recording is given in, the antenna follows the sky targets
+const(
+	FrameAzEl = "azel"
+	FrameEquatorial = "radec"
+	FrameGalactic = "galactic"
+)
+
+// smallest grid step in degrees and largest number of grid points
+const(
+	MinStep = 0.01
+	MaxGridPoints = 10000
 )
 
 var db *gorm.DB
 
+// what the rotator can reach
+var mount rotator.Limits
+
+// where the telescope is, for local times
+var station astro.Observer
+
 type RecordStatus string
 
 type Recording struct {
@@ -37,16 +70,61 @@ type Recording struct {
 	ElStep		float32 `json:"el_step"`
 	CalcTime    int64   `json:"calc_time"`
 	Status		RecordStatus `json:"status"`
+	Error		string	`json:"error"`
+	Priority	int		`json:"priority"`
+	Preempt		bool	`json:"preempt"`
+	SeriesId	int64	`json:"series_id,omitempty"`
+	SurveyId	int64	`json:"survey_id,omitempty"`
+	// sky target, a name from the catalog, or J2000 right ascension and
+	// declination or galactic longitude and latitude in degrees, the grid
+	// ranges and steps are offsets around it
+	Target		string	`json:"target"`
+	Mode		string	`json:"mode"`
+	Frame		string	`json:"frame"`
+	Ra			float64	`json:"ra"`
+	Dec			float64	`json:"dec"`
+	L			float64	`json:"l"`
+	B			float64	`json:"b"`
+	// drift scans: milliseconds between timestamp markers, and the J2000
+	// right ascension covered by the beam and its declination once made
+	MarkerInterval	int64	`json:"marker_interval"`
+	ScanRaStart	float64	`json:"scan_ra_start"`
+	ScanRaEnd	float64	`json:"scan_ra_end"`
+	ScanDec		float64	`json:"scan_dec"`
+	// retries: tries per point, runs allowed, milliseconds to wait before
+	// the first retry, how late a retried run may start compared to its
+	// original time (0 for not at all)
+	PointAttempts	int	`json:"point_attempts"`
+	RunAttempts	int		`json:"run_attempts"`
+	Backoff		int64	`json:"backoff"`
+	MaxSlip		int64	`json:"max_slip"`
+	Attempts	int		`json:"attempts"`
+	OriginalTime	int64	`json:"original_time"`
+	Conflicts	[]int64	`json:"conflicts,omitempty" gorm:"-"`
+	History		[]Attempt	`json:"history,omitempty" gorm:"-"`
+	Trajectory	[]TrajectoryPoint	`json:"trajectory,omitempty" gorm:"-"`
+}
+
+// a position of the antenna
+type Pointing struct {
+	Az			float32	`json:"az"`
+	El			float32	`json:"el"`
 }
 
-type Notification struct {}
+// sent to the scheduler when a recording changes, Id 0 means anything may
+// have changed
+type Notification struct {
+	Id			int64
+}
 
 func init() {
 	// connect to the database and create the tables if needed
 	conf := config.GetConfig()
 	database.ConnectDB(conf.Database)
 	db = database.GetDB()
-	db.AutoMigrate(&Recording{})
+	mount = rotator.MountLimits(conf)
+	station = astro.NewObserver(conf.Station)
+	db.AutoMigrate(&Recording{}, &PointProgress{}, &Series{}, &Blackout{}, &Attempt{}, &TrajectoryPoint{}, &Survey{}, &SurveyPoint{})
 }
 
 // add a recording to the database
@@ -61,10 +139,159 @@ func (r *Recording) Update() *Recording {
 	return r
 }
 
-// calculate estimated time for the recording
-func (r* Recording) EstimateTime() {
-	// (record time * wait ) * number of points * 1000 (milliseconds)
-	r.CalcTime = (int64(r.RecTime) * int64(r.WaitTime)) * int64((r.AzRange / r.AzStep) * (r.ElRange / r.ElStep)) * 1000
+// positions of the grid in the order they are recorded, a step of 0 means
+// only the center along that axis, azimuths in [0, 360) and elevations
+// clamped to the mount limits
+func (r *Recording) Grid() []Pointing {
+	var grid []Pointing
+	// parked in a single place
+	if r.Mode == ModeDrift {
+		return []Pointing{r.DriftPointing()}
+	}
+	// offsets around a moving target
+	if r.Tracked() {
+		for _, az := range gridAxis(0, r.AzRange, r.AzStep) {
+			for _, el := range gridAxis(0, r.ElRange, r.ElStep) {
+				grid = append(grid, Pointing{Az: az, El: el})
+			}
+		}
+		return grid
+	}
+	seen := map[Pointing]bool{}
+	for _, az := range gridAxis(r.Az, r.AzRange, r.AzStep) {
+		for _, el := range gridAxis(r.El, r.ElRange, r.ElStep) {
+			p := Pointing{Az: rotator.NormalizeAz(az), El: mount.ClampEl(el)}
+			// clamping may repeat positions
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			grid = append(grid, p)
+		}
+	}
+	return grid
+}
+
+// values along one axis of the grid
+func gridAxis(center float32, span float32, step float32) []float32 {
+	values := make([]float32, axisPoints(span, step))
+	for i := range values {
+		values[i] = center - span/2 + float32(i) * step
+	}
+	return values
+}
+
+// number of values along one axis of the grid, counted rather than
+// stepped so tiny steps can't stall on float32 rounding
+func axisPoints(span float32, step float32) int {
+	if step <= 0 {
+		return 1
+	}
+	return int(math.Floor(float64(span)/float64(step) + 1e-6)) + 1
+}
+
+// name of the data file for a position of the grid
+func (r *Recording) DataFile(p Pointing) string {
+	// tagged with the galactic coordinates
+	if r.Frame == FrameGalactic && r.Target == "" {
+		return fmt.Sprintf("%d-l%.1f-b%+.1f-off%+.1f%+.1f.iq", r.Id, r.L, r.B, p.Az, p.El)
+	}
+	if r.Tracked() {
+		return fmt.Sprintf("%d-off%+.1f%+.1f.iq", r.Id, p.Az, p.El)
+	}
+	return fmt.Sprintf("%d-%3.1f-%3.1f.iq", r.Id, p.Az, p.El)
+}
+
+// does the antenna follow a target in the sky?
+func (r *Recording) Tracked() bool {
+	if r.Mode == ModeDrift {
+		return false
+	}
+	return r.Target != "" || r.Frame == FrameEquatorial || r.Frame == FrameGalactic
+}
+
+// is the catalog target the Sun?
+func (r *Recording) SunTarget() bool {
+	target, ok := catalog.Lookup(r.Target)
+	return ok && target.Kind == catalog.KindSun
+}
+
+// where a drift scan parks, the az/el of the recording clamped to the
+// mount limits, or where its declination crosses the meridian, which may
+// be out of them
+func (r *Recording) DriftPointing() Pointing {
+	if r.Frame == FrameEquatorial {
+		az, el := transitPosition(r.Dec)
+		return Pointing{Az: az, El: el}
+	}
+	return Pointing{Az: rotator.NormalizeAz(r.Az), El: mount.ClampEl(r.El)}
+}
+
+// azimuth and elevation where a declination crosses the meridian at the
+// station, south of the zenith or north of it
+func transitPosition(dec float64) (float32, float32) {
+	if dec <= station.Lat {
+		return 180, float32(90 - station.Lat + dec)
+	}
+	return 0, float32(90 + station.Lat - dec)
+}
+
+// J2000 right ascension and declination of the target at a time
+func (r *Recording) Equatorial(t time.Time) (float64, float64) {
+	if target, ok := catalog.Lookup(r.Target); ok {
+		return target.Equatorial(t)
+	}
+	if r.Frame == FrameGalactic {
+		return astro.GalacticToEquatorial(r.L, r.B)
+	}
+	return r.Ra, r.Dec
+}
+
+// azimuth and elevation of the target at a time
+func (r *Recording) Horizontal(t time.Time) (float64, float64) {
+	if target, ok := catalog.Lookup(r.Target); ok {
+		return target.Horizontal(station, t)
+	}
+	ra, dec := r.Equatorial(t)
+	return station.Horizontal(ra, dec, t)
+}
+
+// where to point for a position of the grid at a time, azimuth in
+// [0, 360), the elevation of sky targets is not clamped and may be out of
+// the mount limits
+func (r *Recording) PointingAt(p Pointing, t time.Time) Pointing {
+	if !r.Tracked() {
+		return p
+	}
+	az, el := r.Horizontal(t)
+	return Pointing{
+		Az: rotator.NormalizeAz(float32(az) + p.Az),
+		El: float32(el) + p.El,
+	}
+}
+
+// would the points of two recordings capture the same data? The settings
+// of the receiver and what the grid is centered on have to match
+func (r *Recording) SameCapture(o *Recording) bool {
+	return r.Frequency == o.Frequency && r.SampleRate == o.SampleRate && r.Gain == o.Gain &&
+		r.RecTime == o.RecTime && r.Mode == o.Mode && r.Frame == o.Frame &&
+		r.Target == o.Target && r.Az == o.Az && r.El == o.El &&
+		r.Ra == o.Ra && r.Dec == o.Dec && r.L == o.L && r.B == o.B
+}
+
+// delete a recording
+func (r *Recording) Delete() {
+	db.Where("id=?", r.Id).Delete(&Recording{})
+	r.ClearProgress()
+	r.ClearHistory()
+	r.ClearTrajectory()
+}
+
+// calculate estimated time for the recording given the time spent moving
+// the rotor (milliseconds)
+func (r* Recording) EstimateTime(slew int64) {
+	// (record time + wait) * number of points + slew (milliseconds)
+	r.CalcTime = (r.RecTime + r.WaitTime) * int64(len(r.Grid())) + slew
 }
 
 // check recording fields
@@ -82,6 +309,54 @@ func (r* Recording) Check() error {
 	if r.AzRange < 0 || r.AzStep < 0 || r.ElStep < 0 || r.ElRange < 0 {
 		return fmt.Errorf("Movement ranges and steps can't be negative")
 	}
+	if r.AzRange > 360 || r.ElRange > 180 {
+		return fmt.Errorf("Movement ranges can't be larger than 360 (az) and 180 (el) degrees")
+	}
+	if (r.AzStep > 0 && r.AzStep < MinStep) || (r.ElStep > 0 && r.ElStep < MinStep) {
+		return fmt.Errorf("Movement steps can't be smaller than %.2f degrees", MinStep)
+	}
+	if n := axisPoints(r.AzRange, r.AzStep) * axisPoints(r.ElRange, r.ElStep); n > MaxGridPoints {
+		return fmt.Errorf("Grid of %d points, at most %d allowed", n, MaxGridPoints)
+	}
+	if r.PointAttempts < 0 || r.RunAttempts < 0 || r.Backoff < 0 || r.MaxSlip < 0 {
+		return fmt.Errorf("Retry settings can't be negative")
+	}
+	if _, ok := catalog.Lookup(r.Target); r.Target != "" && !ok {
+		return fmt.Errorf("Unknown target %q", r.Target)
+	}
+	switch r.Frame {
+	case "", FrameAzEl:
+	case FrameEquatorial:
+		if r.Dec < -90 || r.Dec > 90 {
+			return fmt.Errorf("Declination out of range")
+		}
+	case FrameGalactic:
+		if r.B < -90 || r.B > 90 {
+			return fmt.Errorf("Galactic latitude out of range")
+		}
+	default:
+		return fmt.Errorf("Unknown frame %q", r.Frame)
+	}
+	switch r.Mode {
+	case "", ModeGrid:
+	case ModeDrift:
+		if r.AzRange != 0 || r.ElRange != 0 {
+			return fmt.Errorf("Drift scans have a single pointing")
+		}
+		if r.Target != "" || (r.Frame != "" && r.Frame != FrameAzEl && r.Frame != FrameEquatorial) {
+			return fmt.Errorf("Drift scans take an az/el or a declination")
+		}
+		if r.Frame == FrameEquatorial {
+			if _, el := transitPosition(r.Dec); el <= 0 {
+				return fmt.Errorf("Declination never rises")
+			}
+		}
+		if r.MarkerInterval < 0 {
+			return fmt.Errorf("Marker interval can't be negative")
+		}
+	default:
+		return fmt.Errorf("Unknown mode %q", r.Mode)
+	}
 	
 	return nil
 }
@@ -90,6 +365,13 @@ func (r* Recording) Check() error {
 // TODO: don't expose this API or remove
 func ClearDB() {
 	db.Where("1 = 1").Delete(&Recording{})
+	db.Where("1 = 1").Delete(&PointProgress{})
+	db.Where("1 = 1").Delete(&Series{})
+	db.Where("1 = 1").Delete(&Blackout{})
+	db.Where("1 = 1").Delete(&Attempt{})
+	db.Where("1 = 1").Delete(&TrajectoryPoint{})
+	db.Where("1 = 1").Delete(&Survey{})
+	db.Where("1 = 1").Delete(&SurveyPoint{})
 }
 
 // Get all recordings
@@ -99,6 +381,12 @@ func GetRecordings() []Recording {
 	return Recordings
 }
 
+// Get all recordings with a status
+func GetRecordingsByStatus(status RecordStatus) []Recording {
+	var Recordings []Recording
+	db.Where("status=?", status).Find(&Recordings)
+	return Recordings
+}
 
 // Get a recording by it's ID
 func GetRecordingById(Id int64) (*Recording, *gorm.DB) {
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// a grid point of a recording that has been fully captured
+type PointProgress struct {
+	gorm.Model
+	RecordingId	int64	`json:"recording_id"`
+	Point		int		`json:"point"`
+	Az			float32	`json:"az"`
+	El			float32	`json:"el"`
+	File		string	`json:"file"`
+	Bytes		int64	`json:"bytes"`
+}
+
+// add a captured point to the database
+func (p *PointProgress) Create() *PointProgress {
+	db.Create(&p)
+	return p
+}
+
+// get the captured points of a recording in grid order
+func (r *Recording) Progress() []PointProgress {
+	var progress []PointProgress
+	db.Where("recording_id=?", r.Id).Order("point").Find(&progress)
+	return progress
+}
+
+// forget the captured points of a recording
+func (r *Recording) ClearProgress() {
+	db.Where("recording_id=?", r.Id).Delete(&PointProgress{})
+}
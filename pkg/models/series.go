@@ -0,0 +1,235 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"carlosapi/pkg/recurrence"
+	"gorm.io/gorm"
+)
+
+// a recurring recording, a template that spawns a Recording for each
+// occurrence, times in milliseconds
+type Series struct {
+	gorm.Model
+	Id			int64	`json:"id"`
+	User		string	`json:"user"`
+	Frequency	int 	`json:"frequency"`
+	SampleRate	int 	`json:"sample_rate"`
+	Gain		int 	`json:"gain"`
+	RecTime		int64	`json:"rec_time"`
+	WaitTime	int64	`json:"wait_time"`
+	Az          float32 `json:"az"`
+	El          float32 `json:"el"`
+	AzRange		float32 `json:"az_range"`
+	AzStep		float32 `json:"az_step"`
+	ElRange		float32 `json:"el_range"`
+	ElStep		float32 `json:"el_step"`
+	Target		string	`json:"target"`
+	Mode		string	`json:"mode"`
+	MarkerInterval	int64	`json:"marker_interval"`
+	Frame		string	`json:"frame"`
+	Ra			float64	`json:"ra"`
+	Dec			float64	`json:"dec"`
+	L			float64	`json:"l"`
+	B			float64	`json:"b"`
+	Priority	int		`json:"priority"`
+	Preempt		bool	`json:"preempt"`
+	PointAttempts	int	`json:"point_attempts"`
+	RunAttempts	int		`json:"run_attempts"`
+	Backoff		int64	`json:"backoff"`
+	MaxSlip		int64	`json:"max_slip"`
+	// "cron" in "timezone" (UTC if not set) or "sidereal" repeating every
+	// sidereal day from "start"
+	Cron		string	`json:"cron"`
+	Timezone	string	`json:"timezone"`
+	Sidereal	bool	`json:"sidereal"`
+	Start		int64	`json:"start"`
+	// 0 means no limit
+	Until		int64	`json:"until"`
+	MaxOccurrences	int	`json:"max_occurrences"`
+	Occurrences	int		`json:"occurrences"`
+	// time of the next occurrence to spawn, 0 when the series is over
+	Next		int64	`json:"next"`
+	Paused		bool	`json:"paused"`
+}
+
+// add a series to the database
+func (s *Series) Create() *Series {
+	db.Create(&s)
+	return s
+}
+
+// update a series
+func (s *Series) Update() *Series {
+	db.Save(&s)
+	return s
+}
+
+// delete a series, the recordings it spawned stay
+func (s *Series) Delete() {
+	db.Where("id=?", s.Id).Delete(&Series{})
+}
+
+// check series fields and set the time of the first occurrence
+func (s *Series) Check() error {
+	if (s.Cron == "") == !s.Sidereal {
+		return fmt.Errorf("Either cron or sidereal is needed")
+	}
+	if s.Cron != "" {
+		if _, err := recurrence.ParseCron(s.Cron); err != nil {
+			return err
+		}
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("Unknown timezone %q", s.Timezone)
+		}
+	}
+	if s.Sidereal && s.Start == 0 {
+		return fmt.Errorf("Sidereal series need a start time")
+	}
+	if s.MaxOccurrences < 0 {
+		return fmt.Errorf("Max occurrences can't be negative")
+	}
+
+	s.Next = s.first()
+	if s.Next == 0 {
+		return fmt.Errorf("Series has no occurrence")
+	}
+	// the template has to make a valid recording
+	rec := s.Occurrence()
+	return rec.Check()
+}
+
+// time of the first occurrence, not before start or now
+func (s *Series) first() int64 {
+	from := time.Now().UnixMilli()
+	if s.Sidereal {
+		return s.after(s.Start - 1, from)
+	}
+	if s.Start > from {
+		from = s.Start
+	}
+	return s.after(from - 1, from)
+}
+
+// time of the first occurrence after t that is not before "from", 0 if
+// the series is over by then
+func (s *Series) after(t int64, from int64) int64 {
+	var next int64
+	if s.Sidereal {
+		day := recurrence.SiderealDay.Milliseconds()
+		// the start is an occurrence, then one per sidereal day
+		next = s.Start
+		if t >= s.Start {
+			next = s.Start + ((t - s.Start) / day + 1) * day
+		}
+		if next < from {
+			next += (from - next + day - 1) / day * day
+		}
+	} else {
+		cron, err := recurrence.ParseCron(s.Cron)
+		if err != nil {
+			return 0
+		}
+		if t < from - 1 {
+			t = from - 1
+		}
+		// "" is UTC
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return 0
+		}
+		nextTime := cron.Next(time.UnixMilli(t).In(loc))
+		if nextTime.IsZero() {
+			return 0
+		}
+		next = nextTime.UnixMilli()
+	}
+	if s.Until > 0 && next > s.Until {
+		return 0
+	}
+	return next
+}
+
+// moves the next occurrence to now if it is in the past, e.g. after a
+// pause
+func (s *Series) CatchUp() {
+	now := time.Now().UnixMilli()
+	if s.Next != 0 && s.Next < now {
+		s.Next = s.after(s.Next - 1, now)
+	}
+}
+
+// is there nothing left to spawn?
+func (s *Series) Over() bool {
+	return s.Next == 0 || (s.MaxOccurrences > 0 && s.Occurrences >= s.MaxOccurrences)
+}
+
+// the recording of the next occurrence, not saved
+func (s *Series) Occurrence() Recording {
+	return Recording{
+		User:		s.User,
+		Time:		s.Next,
+		Frequency:	s.Frequency,
+		SampleRate:	s.SampleRate,
+		Gain:		s.Gain,
+		RecTime:	s.RecTime,
+		WaitTime:	s.WaitTime,
+		Az:			s.Az,
+		El:			s.El,
+		AzRange:	s.AzRange,
+		AzStep:		s.AzStep,
+		ElRange:	s.ElRange,
+		ElStep:		s.ElStep,
+		Target:		s.Target,
+		Mode:		s.Mode,
+		MarkerInterval:	s.MarkerInterval,
+		Frame:		s.Frame,
+		Ra:			s.Ra,
+		Dec:		s.Dec,
+		L:			s.L,
+		B:			s.B,
+		Priority:	s.Priority,
+		Preempt:	s.Preempt,
+		PointAttempts:	s.PointAttempts,
+		RunAttempts:	s.RunAttempts,
+		Backoff:	s.Backoff,
+		MaxSlip:	s.MaxSlip,
+		SeriesId:	s.Id,
+		Status:		Created,
+	}
+}
+
+// moves to the occurrence after the next one, skipping those already in
+// the past
+func (s *Series) Advance() {
+	s.Occurrences++
+	s.Next = s.after(s.Next, time.Now().UnixMilli())
+}
+
+// moves to the occurrence after the next one without spawning it, it
+// doesn't count
+func (s *Series) Skip() {
+	s.Next = s.after(s.Next, time.Now().UnixMilli())
+}
+
+// the occurrence spawned and not started yet, if any
+func (s *Series) Pending() (*Recording, bool) {
+	var rec Recording
+	result := db.Where("series_id=? AND status=?", s.Id, Created).First(&rec)
+	return &rec, result.Error == nil
+}
+
+// Get all series
+func GetSeries() []Series {
+	var series []Series
+	db.Find(&series)
+	return series
+}
+
+// Get a series by it's ID
+func GetSeriesById(Id int64) (*Series, *gorm.DB) {
+	var series Series
+	result := db.Where("id=?", Id).First(&series)
+	return &series, result
+}
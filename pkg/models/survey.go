@@ -0,0 +1,189 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"carlosapi/pkg/astro"
+	"gorm.io/gorm"
+)
+
+// most longitudes in a survey, every 0.1 degrees all around
+const MaxSurveyPoints = 3600
+
+// a galactic plane survey, one recording per galactic longitude at b=0 each
+// scheduled around the time it transits, times in milliseconds
+type Survey struct {
+	gorm.Model
+	Id			int64	`json:"id"`
+	User		string	`json:"user"`
+	Frequency	int 	`json:"frequency"`
+	SampleRate	int 	`json:"sample_rate"`
+	Gain		int 	`json:"gain"`
+	RecTime		int64	`json:"rec_time"`
+	WaitTime	int64	`json:"wait_time"`
+	Priority	int		`json:"priority"`
+	Preempt		bool	`json:"preempt"`
+	PointAttempts	int	`json:"point_attempts"`
+	RunAttempts	int		`json:"run_attempts"`
+	Backoff		int64	`json:"backoff"`
+	MaxSlip		int64	`json:"max_slip"`
+	// galactic longitudes in degrees, from l_from up to l_to every l_step,
+	// through 360 if l_to is smaller
+	LFrom		float64	`json:"l_from"`
+	LTo			float64	`json:"l_to"`
+	LStep		float64	`json:"l_step"`
+	// no point before this time, 0 for now
+	Start		int64	`json:"start"`
+	Points		[]SurveyPoint	`json:"points,omitempty" gorm:"-"`
+}
+
+// a galactic longitude of a survey with the recording capturing it, or why
+// it was left out
+type SurveyPoint struct {
+	gorm.Model
+	SurveyId	int64	`json:"survey_id"`
+	L			float64	`json:"l"`
+	B			float64	`json:"b"`
+	// time of its highest elevation (degrees)
+	Transit		int64	`json:"transit"`
+	MaxEl		float64	`json:"max_el"`
+	RecordingId	int64	`json:"recording_id,omitempty"`
+	Status		string	`json:"status,omitempty" gorm:"-"`
+	Skipped		string	`json:"skipped,omitempty"`
+}
+
+// add a survey to the database
+func (s *Survey) Create() *Survey {
+	db.Create(&s)
+	return s
+}
+
+// delete a survey and its points, the recordings stay
+func (s *Survey) Delete() {
+	db.Where("id=?", s.Id).Delete(&Survey{})
+	db.Where("survey_id=?", s.Id).Delete(&SurveyPoint{})
+}
+
+// check survey fields
+func (s *Survey) Check() error {
+	if s.LStep < MinStep {
+		return fmt.Errorf("Longitude step can't be smaller than %.2f degrees", MinStep)
+	}
+	if s.LFrom < 0 || s.LFrom > 360 || s.LTo < 0 || s.LTo > 360 {
+		return fmt.Errorf("Longitudes must be between 0 and 360")
+	}
+	if n := s.count(); n > MaxSurveyPoints {
+		return fmt.Errorf("Survey of %d longitudes, at most %d allowed", n, MaxSurveyPoints)
+	}
+	// the template has to make a valid recording
+	rec := s.Recording(s.LFrom)
+	rec.Time = time.Now().Add(time.Minute).UnixMilli()
+	return rec.Check()
+}
+
+// number of longitudes of the survey, not going around more than once
+func (s *Survey) count() int {
+	span := s.LTo - s.LFrom
+	if span < 0 {
+		span += 360
+	}
+	n := int(math.Floor(span/s.LStep + 1e-6)) + 1
+	// l_from again after a full turn
+	if float64(n-1)*s.LStep >= 360-1e-6 {
+		n--
+	}
+	return n
+}
+
+// galactic longitudes of the survey in [0, 360)
+func (s *Survey) Longitudes() []float64 {
+	n := s.count()
+	ls := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		ls = append(ls, astro.Normalize(s.LFrom + float64(i)*s.LStep))
+	}
+	return ls
+}
+
+// the recording of a longitude, without time, not saved
+func (s *Survey) Recording(l float64) Recording {
+	return Recording{
+		User:		s.User,
+		Frequency:	s.Frequency,
+		SampleRate:	s.SampleRate,
+		Gain:		s.Gain,
+		RecTime:	s.RecTime,
+		WaitTime:	s.WaitTime,
+		Frame:		FrameGalactic,
+		L:			l,
+		B:			0,
+		Priority:	s.Priority,
+		Preempt:	s.Preempt,
+		PointAttempts:	s.PointAttempts,
+		RunAttempts:	s.RunAttempts,
+		Backoff:	s.Backoff,
+		MaxSlip:	s.MaxSlip,
+		SurveyId:	s.Id,
+		Status:		Created,
+	}
+}
+
+// next time after "from" a point of the galactic plane is highest in the
+// sky at the station, its elevation then, and how long it stays above h0
+// (degrees) on each side of the transit, 0 if it doesn't get there and 12
+// hours if it never sets
+func Transit(l float64, b float64, from time.Time, h0 float64) (time.Time, float64, time.Duration) {
+	ra, dec := astro.GalacticToEquatorial(l, b)
+	elevation := func(t time.Time) float64 {
+		_, el := station.Horizontal(ra, dec, t)
+		return el
+	}
+	// only the transit is needed, nothing rises above the zenith
+	_, _, transit := astro.Events(elevation, from, 90)
+	maxEl := elevation(transit)
+	if maxEl < h0 {
+		return transit, maxEl, 0
+	}
+	// sidereal motion is symmetric around the transit
+	_, set, _ := astro.Events(elevation, transit, h0)
+	if set.IsZero() {
+		return transit, maxEl, 12 * time.Hour
+	}
+	return transit, maxEl, set.Sub(transit)
+}
+
+// add a point to its survey
+func (p *SurveyPoint) Create() *SurveyPoint {
+	db.Create(&p)
+	return p
+}
+
+// loads the points of a survey with the status of their recordings
+func (s *Survey) LoadPoints() {
+	s.Points = nil
+	db.Where("survey_id=?", s.Id).Order("id").Find(&s.Points)
+	for i, p := range s.Points {
+		if p.RecordingId == 0 {
+			continue
+		}
+		if rec, result := GetRecordingById(p.RecordingId); result.Error == nil {
+			s.Points[i].Status = string(rec.Status)
+		}
+	}
+}
+
+// Get all surveys
+func GetSurveys() []Survey {
+	var surveys []Survey
+	db.Find(&surveys)
+	return surveys
+}
+
+// Get a survey by it's ID
+func GetSurveyById(Id int64) (*Survey, *gorm.DB) {
+	var survey Survey
+	result := db.Where("id=?", Id).First(&survey)
+	return &survey, result
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// where the antenna was while capturing a grid point, time in milliseconds,
+// az/el as read from the rotator and the target az/el it was following
+type TrajectoryPoint struct {
+	gorm.Model
+	RecordingId	int64	`json:"recording_id"`
+	Point		int		`json:"point"`
+	Time		int64	`json:"time"`
+	Az			float32	`json:"az"`
+	El			float32	`json:"el"`
+	TargetAz	float32	`json:"target_az"`
+	TargetEl	float32	`json:"target_el"`
+}
+
+// add a sample to the trajectory of its recording
+func (t *TrajectoryPoint) Create() *TrajectoryPoint {
+	db.Create(&t)
+	return t
+}
+
+// loads the trajectory of a recording in time order
+func (r *Recording) LoadTrajectory() {
+	r.Trajectory = nil
+	db.Where("recording_id=?", r.Id).Order("time, id").Find(&r.Trajectory)
+}
+
+// forget the trajectory of one point of a recording, before capturing it
+// again
+func (r *Recording) ClearPointTrajectory(point int) {
+	db.Where("recording_id=? AND point=?", r.Id, point).Delete(&TrajectoryPoint{})
+}
+
+// forget the trajectory of a recording
+func (r *Recording) ClearTrajectory() {
+	db.Where("recording_id=?", r.Id).Delete(&TrajectoryPoint{})
+}
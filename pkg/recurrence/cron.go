@@ -0,0 +1,137 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// length of a sidereal day, the time it takes the sky to come back to the
+// same position
+const SiderealDay = 86164090500 * time.Microsecond
+
+// Cron is a parsed cron expression: minute hour day-of-month month
+// day-of-week, each field a set of allowed values
+type Cron struct {
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+	// day of month or week is "*"
+	domAny bool
+	dowAny bool
+}
+
+// parses a standard 5 fields cron expression, fields can be "*", values,
+// ranges "a-b", lists "a,b" and steps "*/n" or "a-b/n", day of week 0 or 7
+// is Sunday
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Cron expression needs 5 fields, got %d", len(fields))
+	}
+	c := &Cron{}
+	var err error
+	if _, err = parseField(fields[0], 0, 59, c.minute[:]); err != nil {
+		return nil, err
+	}
+	if _, err = parseField(fields[1], 0, 23, c.hour[:]); err != nil {
+		return nil, err
+	}
+	if c.domAny, err = parseField(fields[2], 1, 31, c.dom[:]); err != nil {
+		return nil, err
+	}
+	if _, err = parseField(fields[3], 1, 12, c.month[:]); err != nil {
+		return nil, err
+	}
+	var dow [8]bool
+	if c.dowAny, err = parseField(fields[4], 0, 7, dow[:]); err != nil {
+		return nil, err
+	}
+	copy(c.dow[:], dow[:7])
+	c.dow[0] = c.dow[0] || dow[7]
+	return c, nil
+}
+
+// sets the allowed values of a field, returns true if it is "*"
+func parseField(field string, min int, max int, values []bool) (bool, error) {
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rng = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return false, fmt.Errorf("Bad step in cron field %q", field)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return false, fmt.Errorf("Bad value in cron field %q", field)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return false, fmt.Errorf("Bad value in cron field %q", field)
+				}
+			} else if step > 1 {
+				// "a/n" means from a to the end
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return false, fmt.Errorf("Value out of range in cron field %q", field)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return field == "*", nil
+}
+
+// the first time after t matching the expression, in the location of t,
+// zero if there is none in the next 5 years (e.g. February 30th)
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.month[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.day(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// does the day of t match, like cron a restricted day of month or day of
+// week matches either
+func (c *Cron) day(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[t.Weekday()]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
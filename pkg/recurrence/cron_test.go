@@ -0,0 +1,141 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+// a time in UTC to the minute
+func utc(year int, month time.Month, day int, hour int, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-01-01 is a Thursday
+	from := utc(2026, 1, 1, 10, 7)
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, utc(2026, 1, 1, 10, 8)},
+		// seconds are dropped, the next minute is the first candidate
+		{"* * * * *", from.Add(30 * time.Second), utc(2026, 1, 1, 10, 8)},
+		{"0 12 * * *", from, utc(2026, 1, 1, 12, 0)},
+		{"0 9 * * *", from, utc(2026, 1, 2, 9, 0)},
+		// steps
+		{"*/15 * * * *", from, utc(2026, 1, 1, 10, 15)},
+		{"*/15 * * * *", utc(2026, 1, 1, 10, 45), utc(2026, 1, 1, 11, 0)},
+		{"5/20 * * * *", from, utc(2026, 1, 1, 10, 25)},
+		{"0 8-18/4 * * *", from, utc(2026, 1, 1, 12, 0)},
+		{"0 8-18/4 * * *", utc(2026, 1, 1, 16, 0), utc(2026, 1, 2, 8, 0)},
+		// ranges and lists
+		{"30 9-17 * * *", from, utc(2026, 1, 1, 10, 30)},
+		{"0,20,40 * * * *", from, utc(2026, 1, 1, 10, 20)},
+		{"0 6,22 * * *", from, utc(2026, 1, 1, 22, 0)},
+		{"0 0 1 3,6 *", from, utc(2026, 3, 1, 0, 0)},
+		// day of week, 0 and 7 are Sunday
+		{"0 0 * * 1-5", utc(2026, 1, 2, 12, 0), utc(2026, 1, 5, 0, 0)},
+		{"0 0 * * 0", from, utc(2026, 1, 4, 0, 0)},
+		{"0 0 * * 7", from, utc(2026, 1, 4, 0, 0)},
+		// a restricted day of month and day of week match either: the 15th
+		// or a Monday
+		{"0 0 15 * 1", from, utc(2026, 1, 5, 0, 0)},
+		{"0 0 15 * 1", utc(2026, 1, 13, 0, 0), utc(2026, 1, 15, 0, 0)},
+		// only a restricted one counts
+		{"0 0 15 * *", from, utc(2026, 1, 15, 0, 0)},
+		{"0 0 */10 * *", from, utc(2026, 1, 11, 0, 0)},
+		// February 29th only in leap years
+		{"0 0 29 2 *", from, utc(2028, 2, 29, 0, 0)},
+		{"0 0 31 * *", utc(2026, 4, 1, 0, 0), utc(2026, 5, 31, 0, 0)},
+		// never happens, zero after 5 years of looking
+		{"0 0 30 2 *", from, time.Time{}},
+		{"0 0 31 4 *", from, time.Time{}},
+		// across the end of the year
+		{"0 0 1 1 *", utc(2026, 12, 31, 23, 59), utc(2027, 1, 1, 0, 0)},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", test.expr, err)
+			continue
+		}
+		if got := c.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("Next(%q, %v) = %v, want %v", test.expr, test.from, got, test.want)
+		}
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	local := func(month time.Month, day int, hour int, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, madrid)
+	}
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// clocks go from 2:00 to 3:00 on March 29th, 2:30 doesn't exist
+		// that day
+		{"30 2 * * *", local(3, 28, 12, 0), local(3, 30, 2, 30)},
+		{"30 3 * * *", local(3, 28, 12, 0), local(3, 29, 3, 30)},
+		// the hour is in local time on both sides of the change
+		{"0 12 * * *", local(3, 28, 13, 0), local(3, 29, 12, 0)},
+		{"0 12 * * *", local(10, 24, 13, 0), local(10, 25, 12, 0)},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", test.expr, err)
+		}
+		if got := c.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("Next(%q, %v) = %v, want %v", test.expr, test.from, got, test.want)
+		}
+	}
+	// clocks go back from 3:00 to 2:00 on October 25th, 2:30 happens twice
+	// and runs once
+	c, _ := ParseCron("30 2 * * *")
+	first := c.Next(local(10, 24, 12, 0))
+	if first.Day() != 25 || first.Hour() != 2 || first.Minute() != 30 {
+		t.Errorf("Next(\"30 2 * * *\") before the change = %v, want October 25th 2:30", first)
+	}
+	if second := c.Next(first); !second.Equal(local(10, 26, 2, 30)) {
+		t.Errorf("Next(\"30 2 * * *\", %v) = %v, want %v", first, second, local(10, 26, 2, 30))
+	}
+
+	// stays in the location it was given
+	c, _ = ParseCron("0 12 * * *")
+	if got := c.Next(local(6, 1, 0, 0)); got.Location() != madrid || got.Hour() != 12 {
+		t.Errorf("Next in Madrid = %v, want 12:00 in Madrid", got)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"a * * * *",
+		"5-a * * * *",
+		"10-5 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"1,,2 * * * *",
+		"-1 * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) accepted", expr)
+		}
+	}
+}
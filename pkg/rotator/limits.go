@@ -0,0 +1,118 @@
+package rotator
+
+import (
+	"carlosapi/pkg/config"
+	"fmt"
+	"math"
+)
+
+// Limits are the positions the mount can reach in degrees, azimuths in the
+// rotator frame which may go past 360 on rotators with overlap
+type Limits struct {
+	MinAz float64
+	MaxAz float64
+	MinEl float64
+	MaxEl float64
+}
+
+// limits of the configured rotator, 0-360 and 0-90 if not set
+func MountLimits(conf config.Config) Limits {
+	l := Limits{conf.Rotator.MinAz, conf.Rotator.MaxAz, conf.Rotator.MinEl, conf.Rotator.MaxEl}
+	if conf.RotatorBackend == BackendSimulated {
+		l = Limits{conf.RotatorSim.MinAz, conf.RotatorSim.MaxAz, conf.RotatorSim.MinEl, conf.RotatorSim.MaxEl}
+	}
+	if l.MinAz == 0 && l.MaxAz == 0 {
+		l.MaxAz = 360
+	}
+	if l.MinEl == 0 && l.MaxEl == 0 {
+		l.MaxEl = 90
+	}
+	return l
+}
+
+// NormalizeAz returns an azimuth in [0, 360)
+func NormalizeAz(az float32) float32 {
+	a := math.Mod(float64(az), 360)
+	if a < 0 {
+		a += 360
+	}
+	// -0.0001 rounds up to 360 in float32
+	if float32(a) >= 360 {
+		return 0
+	}
+	return float32(a)
+}
+
+// ClampEl returns the closest elevation the mount can reach
+func (l Limits) ClampEl(el float32) float32 {
+	return float32(math.Max(l.MinEl, math.Min(l.MaxEl, float64(el))))
+}
+
+// CheckEl returns why the mount can't reach an elevation, or nil
+func (l Limits) CheckEl(el float32) error {
+	if float64(el) < l.MinEl || float64(el) > l.MaxEl {
+		return fmt.Errorf("Elevation %.1f out of the mount limits %.1f-%.1f", el, l.MinEl, l.MaxEl)
+	}
+	return nil
+}
+
+// Path returns the rotator azimuths to visit sky azimuths in order starting
+// from the rotator azimuth "from". Consecutive points are kept on the same
+// turn of the cable wrap, so a scan crossing north continues through it on
+// rotators with overlap instead of unwinding, and the turn closest to
+// "from" is used. If the scan doesn't fit in a single turn each point goes
+// to the position closest to the previous one.
+func (l Limits) Path(from float32, azs []float32) []float32 {
+	if len(azs) == 0 {
+		return nil
+	}
+	// continuous sweep, steps between points are at most half a turn
+	sweep := make([]float64, len(azs))
+	sweep[0] = float64(NormalizeAz(azs[0]))
+	lo, hi := sweep[0], sweep[0]
+	for i := 1; i < len(azs); i++ {
+		d := math.Mod(float64(azs[i]-azs[i-1]), 360)
+		if d > 180 {
+			d -= 360
+		} else if d <= -180 {
+			d += 360
+		}
+		sweep[i] = sweep[i-1] + d
+		lo, hi = math.Min(lo, sweep[i]), math.Max(hi, sweep[i])
+	}
+
+	// whole turns that keep the sweep within the limits
+	best, found := 0.0, false
+	for k := math.Ceil((l.MinAz - lo) / 360); lo+k*360 >= l.MinAz && hi+k*360 <= l.MaxAz; k++ {
+		if !found || math.Abs(sweep[0]+k*360-float64(from)) < math.Abs(sweep[0]+best*360-float64(from)) {
+			best, found = k, true
+		}
+	}
+	path := make([]float32, len(azs))
+	if found {
+		for i := range sweep {
+			path[i] = float32(sweep[i] + best*360)
+		}
+		return path
+	}
+
+	// point by point
+	prev := float64(from)
+	for i, az := range azs {
+		path[i] = float32(l.closest(float64(NormalizeAz(az)), prev))
+		prev = float64(path[i])
+	}
+	return path
+}
+
+// rotator azimuth for a sky azimuth closest to another rotator azimuth, the
+// sky azimuth itself if it can't be reached
+func (l Limits) closest(az float64, to float64) float64 {
+	best, found := az, false
+	for a := az + 360*math.Ceil((l.MinAz-az)/360); a <= l.MaxAz; a += 360 {
+		if !found || math.Abs(a-to) < math.Abs(best-to) {
+			best, found = a, true
+		}
+	}
+	return best
+}
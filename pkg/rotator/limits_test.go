@@ -0,0 +1,62 @@
+package rotator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	standard := Limits{MinAz: 0, MaxAz: 360, MinEl: 0, MaxEl: 90}
+	overlap := Limits{MinAz: 0, MaxAz: 450, MinEl: 0, MaxEl: 90}
+	south := Limits{MinAz: -180, MaxAz: 180, MinEl: 0, MaxEl: 90}
+	limited := Limits{MinAz: 90, MaxAz: 270, MinEl: 0, MaxEl: 90}
+	tests := []struct {
+		name   string
+		limits Limits
+		from   float32
+		azs    []float32
+		want   []float32
+	}{
+		{"empty", standard, 0, nil, nil},
+		{"0-360", standard, 0, []float32{10, 20, 30}, []float32{10, 20, 30}},
+		{"0-360 backwards", standard, 0, []float32{30, 20, 10}, []float32{30, 20, 10}},
+		// can't go through north in one turn, each point goes where it's
+		// closest to the previous one, 360 is north too
+		{"0-360 across north", standard, 0, []float32{350, 0, 10}, []float32{350, 360, 10}},
+		{"0-360 sky azimuths", standard, 0, []float32{-10, 370}, []float32{350, 10}},
+		// with overlap the scan continues past 360 instead of unwinding
+		{"0-450 across north", overlap, 0, []float32{350, 0, 10}, []float32{350, 360, 370}},
+		// the turn closest to where the rotator is
+		{"0-450 first turn", overlap, 0, []float32{30, 40}, []float32{30, 40}},
+		{"0-450 second turn", overlap, 400, []float32{30, 40}, []float32{390, 400}},
+		// centered on south, north is reached at both ends
+		{"-180-180 across north", south, 0, []float32{350, 0, 10}, []float32{-10, 0, 10}},
+		{"-180-180 across south", south, 0, []float32{170, 180, 190}, []float32{170, 180, -170}},
+		// what can't be reached is left as it is
+		{"90-270 north", limited, 180, []float32{0}, []float32{0}},
+		{"90-270", limited, 180, []float32{100, 260}, []float32{100, 260}},
+	}
+	for _, test := range tests {
+		got := test.limits.Path(test.from, test.azs)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: Path(%v, %v) = %v, want %v", test.name, test.from, test.azs, got, test.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(float64(got[i]-test.want[i])) > 1e-3 {
+				t.Errorf("%s: Path(%v, %v) = %v, want %v", test.name, test.from, test.azs, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestNormalizeAz(t *testing.T) {
+	for _, test := range []struct{ az, want float32 }{
+		{0, 0}, {359.5, 359.5}, {360, 0}, {370, 10}, {-10, 350}, {-720, 0}, {-0.000001, 0},
+	} {
+		if got := NormalizeAz(test.az); math.Abs(float64(got-test.want)) > 1e-3 {
+			t.Errorf("NormalizeAz(%v) = %v, want %v", test.az, got, test.want)
+		}
+	}
+}
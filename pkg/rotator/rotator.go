@@ -0,0 +1,137 @@
+package rotator
+
+import (
+	"carlosapi/pkg/config"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// names of the available rotator backends (rotator_backend in config.toml)
+const (
+	BackendNone      = "none"
+	BackendRotctld   = "rotctld"
+	BackendSimulated = "simulated"
+)
+
+// Rotator moves the antenna
+type Rotator interface {
+	// starts moving to a position, returns without waiting to get there
+	SetPosition(az float32, el float32) error
+	// current position
+	GetPosition() (az float32, el float32, err error)
+	// stops moving
+	Stop() error
+	// moves to the park position
+	Park() error
+	// releases the rotator
+	Close() error
+}
+
+// returns the rotator selected by the rotator_backend configuration key,
+// defaults to no rotator
+func NewRotator(conf config.Config) (Rotator, error) {
+	switch conf.RotatorBackend {
+	case "", BackendNone:
+		return &NullRotator{}, nil
+	case BackendRotctld:
+		return NewRotctld(conf.Rotctld), nil
+	case BackendSimulated:
+		return Simulated(conf.RotatorSim), nil
+	default:
+		return nil, fmt.Errorf("Unknown rotator backend %q", conf.RotatorBackend)
+	}
+}
+
+// the simulated rotator is shared by all the jobs so it keeps its position
+var simulated *SimulatedRotator
+var simulatedOnce sync.Once
+
+// returns the shared simulated rotator, created on first use
+func Simulated(conf config.RotatorSimConfig) *SimulatedRotator {
+	simulatedOnce.Do(func() {
+		simulated = NewSimulatedRotator(conf)
+	})
+	return simulated
+}
+
+// moves to a position and waits until the reported position is within the
+// configured tolerance, stops the rotator if ctx is cancelled
+func MoveTo(ctx context.Context, rot Rotator, az float32, el float32, conf config.RotatorConfig) error {
+	err := rot.SetPosition(az, el)
+	if err != nil {
+		return err
+	}
+
+	tolerance := conf.Tolerance
+	if tolerance <= 0 {
+		tolerance = 1
+	}
+	poll := time.Duration(conf.PollInterval) * time.Millisecond
+	if poll <= 0 {
+		poll = 500 * time.Millisecond
+	}
+	timeout := time.Duration(conf.MoveTimeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		curAz, curEl, err := rot.GetPosition()
+		if err != nil {
+			return err
+		}
+		if AzDistance(curAz, az) <= tolerance && math.Abs(float64(curEl-el)) <= tolerance {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			rot.Stop()
+			return fmt.Errorf("Rotator timeout moving to (%3.1f, %3.1f), at (%3.1f, %3.1f)", az, el, curAz, curEl)
+		}
+		select {
+		case <-time.After(poll):
+		case <-ctx.Done():
+			rot.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// smallest angle in degrees between two azimuths
+func AzDistance(a float32, b float32) float64 {
+	d := math.Mod(math.Abs(float64(a-b)), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// NullRotator is used when there is no rotator, it is always where it's told
+type NullRotator struct {
+	az float32
+	el float32
+}
+
+func (r *NullRotator) SetPosition(az float32, el float32) error {
+	r.az, r.el = az, el
+	return nil
+}
+
+func (r *NullRotator) GetPosition() (float32, float32, error) {
+	return r.az, r.el, nil
+}
+
+func (r *NullRotator) Stop() error {
+	return nil
+}
+
+func (r *NullRotator) Park() error {
+	return nil
+}
+
+func (r *NullRotator) Close() error {
+	return nil
+}
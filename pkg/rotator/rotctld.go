@@ -0,0 +1,187 @@
+package rotator
+
+import (
+	"bufio"
+	"carlosapi/pkg/config"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hamlib error codes returned as "RPRT -code"
+var rprtErrors = map[int]string{
+	1:  "invalid parameter",
+	2:  "invalid configuration",
+	3:  "memory shortage",
+	4:  "function not implemented",
+	5:  "communication timed out",
+	6:  "IO error",
+	7:  "internal hamlib error",
+	8:  "protocol error",
+	9:  "command rejected by the rotator",
+	10: "command performed but arg truncated",
+	11: "function not available",
+	12: "VFO not targetable",
+	13: "error talking on the bus",
+	14: "collision on the bus",
+	15: "NULL RIG handle or invalid pointer parameter",
+	16: "invalid VFO",
+	17: "argument out of domain of func",
+}
+
+// RprtError is an error reported by rotctld
+type RprtError struct {
+	Code int
+}
+
+func (e RprtError) Error() string {
+	if msg, ok := rprtErrors[-e.Code]; ok {
+		return fmt.Sprintf("rotctld error %d: %s", e.Code, msg)
+	}
+	return fmt.Sprintf("rotctld error %d", e.Code)
+}
+
+// Rotctld is a client for the hamlib rotctld network daemon
+type Rotctld struct {
+	Conf  config.RotctldConfig
+	Debug bool
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// creates a rotctld client from the [rotctld] configuration
+func NewRotctld(conf config.RotctldConfig) *Rotctld {
+	return &Rotctld{Conf: conf}
+}
+
+// network timeout, 5 seconds by default
+func (r *Rotctld) timeout() time.Duration {
+	if r.Conf.Timeout > 0 {
+		return time.Duration(r.Conf.Timeout) * time.Millisecond
+	}
+	return 5 * time.Second
+}
+
+// connects to rotctld if not connected yet
+func (r *Rotctld) connect() error {
+	if r.conn != nil {
+		return nil
+	}
+	addr := net.JoinHostPort(r.Conf.Host, fmt.Sprint(r.Conf.Port))
+	conn, err := net.DialTimeout("tcp", addr, r.timeout())
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.reader = bufio.NewReader(conn)
+	return nil
+}
+
+// drops the connection after an error so the next command reconnects
+func (r *Rotctld) disconnect() {
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+		r.reader = nil
+	}
+}
+
+// sends a command and returns the lines of the answer, an answer is
+// complete when it has the expected number of value lines or is a RPRT line
+func (r *Rotctld) command(cmd string, values int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+	if r.Debug {
+		log.Printf("\trotctld > %s\n", cmd)
+	}
+	r.conn.SetDeadline(time.Now().Add(r.timeout()))
+	if _, err := r.conn.Write([]byte(cmd + "\n")); err != nil {
+		r.disconnect()
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil {
+			r.disconnect()
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if r.Debug {
+			log.Printf("\trotctld < %s\n", line)
+		}
+		if strings.HasPrefix(line, "RPRT") {
+			code, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "RPRT")))
+			if err != nil {
+				r.disconnect()
+				return nil, fmt.Errorf("Bad rotctld answer %q", line)
+			}
+			if code != 0 {
+				return nil, RprtError{Code: code}
+			}
+			return lines, nil
+		}
+		lines = append(lines, line)
+		if values > 0 && len(lines) == values {
+			return lines, nil
+		}
+	}
+}
+
+// starts moving to a position ("P az el")
+func (r *Rotctld) SetPosition(az float32, el float32) error {
+	_, err := r.command(fmt.Sprintf("P %.2f %.2f", az, el), 0)
+	return err
+}
+
+// current position ("p")
+func (r *Rotctld) GetPosition() (float32, float32, error) {
+	lines, err := r.command("p", 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	az, err := strconv.ParseFloat(lines[0], 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Bad rotctld azimuth %q", lines[0])
+	}
+	el, err := strconv.ParseFloat(lines[1], 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Bad rotctld elevation %q", lines[1])
+	}
+	return float32(az), float32(el), nil
+}
+
+// stops moving ("S")
+func (r *Rotctld) Stop() error {
+	_, err := r.command("S", 0)
+	return err
+}
+
+// moves to the park position ("K")
+func (r *Rotctld) Park() error {
+	_, err := r.command("K", 0)
+	return err
+}
+
+// closes the connection to rotctld
+func (r *Rotctld) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	r.conn.Write([]byte("q\n"))
+	r.disconnect()
+	return nil
+}
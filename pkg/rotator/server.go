@@ -0,0 +1,140 @@
+package rotator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RotctldServer speaks the rotctld protocol on top of any Rotator, so a
+// simulated rotator can stand in for a real rotctld
+type RotctldServer struct {
+	Listener net.Listener
+	Rotator  Rotator
+	Debug    bool
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+	wg    sync.WaitGroup
+}
+
+// listens on addr ("127.0.0.1:0" for a random port), call Serve to accept
+// connections
+func NewRotctldServer(addr string, rot Rotator) (*RotctldServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &RotctldServer{Listener: listener, Rotator: rot, conns: map[net.Conn]bool{}}, nil
+}
+
+// address the server is listening on
+func (s *RotctldServer) Addr() *net.TCPAddr {
+	return s.Listener.Addr().(*net.TCPAddr)
+}
+
+// accepts connections until the server is closed
+func (s *RotctldServer) Serve() {
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// stops listening, disconnects the clients and waits for them to go away
+func (s *RotctldServer) Close() error {
+	err := s.Listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// answers commands until the client quits
+func (s *RotctldServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if s.Debug {
+			log.Printf("\trotctld server < %s\n", strings.TrimSpace(line))
+		}
+		if fields[0] == "q" || fields[0] == "Q" {
+			return
+		}
+		if _, err = conn.Write([]byte(s.answer(fields))); err != nil {
+			return
+		}
+	}
+}
+
+// executes a command and returns the answer
+func (s *RotctldServer) answer(fields []string) string {
+	var err error
+	switch strings.TrimPrefix(fields[0], "\\") {
+	case "P", "set_pos":
+		if len(fields) != 3 {
+			return rprt(RprtError{Code: -1})
+		}
+		az, errAz := strconv.ParseFloat(fields[1], 32)
+		el, errEl := strconv.ParseFloat(fields[2], 32)
+		if errAz != nil || errEl != nil {
+			return rprt(RprtError{Code: -1})
+		}
+		err = s.Rotator.SetPosition(float32(az), float32(el))
+	case "p", "get_pos":
+		az, el, err := s.Rotator.GetPosition()
+		if err != nil {
+			return rprt(err)
+		}
+		return fmt.Sprintf("%.6f\n%.6f\n", az, el)
+	case "S", "stop":
+		err = s.Rotator.Stop()
+	case "K", "park":
+		err = s.Rotator.Park()
+	default:
+		err = RprtError{Code: -4}
+	}
+	return rprt(err)
+}
+
+// RPRT line for an error, IO error if it isn't a rotctld one
+func rprt(err error) string {
+	if err == nil {
+		return "RPRT 0\n"
+	}
+	var rerr RprtError
+	if errors.As(err, &rerr) {
+		return fmt.Sprintf("RPRT %d\n", rerr.Code)
+	}
+	return "RPRT -6\n"
+}
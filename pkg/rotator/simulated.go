@@ -0,0 +1,210 @@
+package rotator
+
+import (
+	"carlosapi/pkg/config"
+	"math"
+	"sync"
+	"time"
+)
+
+// SlewModel describes how fast the axes move, speeds in degrees per second
+// and accelerations in degrees per second squared, 0 for instant
+type SlewModel struct {
+	AzSpeed float64
+	ElSpeed float64
+	AzAccel float64
+	ElAccel float64
+}
+
+// creates a slew model from the [rotator_sim] configuration
+func NewSlewModel(conf config.RotatorSimConfig) SlewModel {
+	return SlewModel{
+		AzSpeed: conf.AzSpeed,
+		ElSpeed: conf.ElSpeed,
+		AzAccel: conf.AzAccel,
+		ElAccel: conf.ElAccel,
+	}
+}
+
+// slew model to estimate movements of the configured rotator
+func EstimateModel(conf config.Config) SlewModel {
+	if conf.RotatorBackend == BackendSimulated {
+		return NewSlewModel(conf.RotatorSim)
+	}
+	return SlewModel{
+		AzSpeed: conf.Rotator.AzSpeed,
+		ElSpeed: conf.Rotator.ElSpeed,
+		AzAccel: conf.Rotator.AzAccel,
+		ElAccel: conf.Rotator.ElAccel,
+	}
+}
+
+// time needed to go from one position to another, both axes move at once
+func (m SlewModel) Time(fromAz, fromEl, toAz, toEl float32) time.Duration {
+	az := axisTime(math.Abs(float64(toAz-fromAz)), m.AzSpeed, m.AzAccel)
+	el := axisTime(math.Abs(float64(toEl-fromEl)), m.ElSpeed, m.ElAccel)
+	return time.Duration(math.Max(az, el) * float64(time.Second))
+}
+
+// seconds to travel a distance with a trapezoidal speed profile
+func axisTime(d float64, v float64, a float64) float64 {
+	if d == 0 {
+		return 0
+	}
+	if v <= 0 {
+		return 0
+	}
+	if a <= 0 {
+		return d / v
+	}
+	// distance used to accelerate and decelerate
+	if ramps := v * v / a; d < ramps {
+		return 2 * math.Sqrt(d/a)
+	}
+	return d/v + v/a
+}
+
+// distance travelled after t seconds on a move of length d
+func axisDistance(d float64, v float64, a float64, t float64) float64 {
+	total := axisTime(d, v, a)
+	if t >= total {
+		return d
+	}
+	if t <= 0 {
+		return 0
+	}
+	if a <= 0 {
+		return v * t
+	}
+	// top speed reached, maybe lower than v on short moves
+	top := math.Min(v, math.Sqrt(d*a))
+	ramp := top / a
+	switch {
+	case t < ramp:
+		return a * t * t / 2
+	case t < total-ramp:
+		return top*ramp/2 + top*(t-ramp)
+	default:
+		left := total - t
+		return d - a*left*left/2
+	}
+}
+
+// SimulatedRotator models a mount with limited speed, acceleration and
+// mechanical limits
+type SimulatedRotator struct {
+	Conf  config.RotatorSimConfig
+	Model SlewModel
+
+	mu      sync.Mutex
+	fromAz  float32
+	fromEl  float32
+	toAz    float32
+	toEl    float32
+	started time.Time
+	moving  time.Duration
+}
+
+// creates a simulated rotator at its park position, without limits it
+// covers the whole sky
+func NewSimulatedRotator(conf config.RotatorSimConfig) *SimulatedRotator {
+	if conf.MinAz == 0 && conf.MaxAz == 0 {
+		conf.MaxAz = 360
+	}
+	if conf.MinEl == 0 && conf.MaxEl == 0 {
+		conf.MaxEl = 90
+	}
+	return &SimulatedRotator{
+		Conf:   conf,
+		Model:  NewSlewModel(conf),
+		fromAz: float32(conf.ParkAz),
+		fromEl: float32(conf.ParkEl),
+		toAz:   float32(conf.ParkAz),
+		toEl:   float32(conf.ParkEl),
+	}
+}
+
+// position at a given time, must be called with the lock held
+func (r *SimulatedRotator) positionAt(t time.Time) (float32, float32) {
+	if r.Conf.Stuck {
+		return r.fromAz, r.fromEl
+	}
+	s := t.Sub(r.started).Seconds()
+	return axisPosition(r.fromAz, r.toAz, r.Model.AzSpeed, r.Model.AzAccel, s),
+		axisPosition(r.fromEl, r.toEl, r.Model.ElSpeed, r.Model.ElAccel, s)
+}
+
+// position of an axis s seconds after starting a move
+func axisPosition(from float32, to float32, v float64, a float64, s float64) float32 {
+	d := float64(to - from)
+	p := axisDistance(math.Abs(d), v, a, s)
+	return from + float32(math.Copysign(p, d))
+}
+
+// starts moving to a position, out of limits positions are rejected
+func (r *SimulatedRotator) SetPosition(az float32, el float32) error {
+	if float64(az) < r.Conf.MinAz || float64(az) > r.Conf.MaxAz ||
+		float64(el) < r.Conf.MinEl || float64(el) > r.Conf.MaxEl {
+		return RprtError{Code: -1}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.stop(now)
+	r.toAz, r.toEl = az, el
+	r.started = now
+	return nil
+}
+
+// current position
+func (r *SimulatedRotator) GetPosition() (float32, float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	az, el := r.positionAt(time.Now())
+	return az, el, nil
+}
+
+// stops where it is, must be called with the lock held
+func (r *SimulatedRotator) stop(now time.Time) {
+	az, el := r.positionAt(now)
+	r.moving += r.slewed(now)
+	r.fromAz, r.fromEl = az, el
+	r.toAz, r.toEl = az, el
+	r.started = now
+}
+
+// stops moving, deceleration is not modelled
+func (r *SimulatedRotator) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stop(time.Now())
+	return nil
+}
+
+// moves to the park position
+func (r *SimulatedRotator) Park() error {
+	return r.SetPosition(float32(r.Conf.ParkAz), float32(r.Conf.ParkEl))
+}
+
+// total time spent slewing so far
+func (r *SimulatedRotator) SlewTime() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.moving + r.slewed(time.Now())
+}
+
+// time spent slewing in the current move, must be called with the lock held
+func (r *SimulatedRotator) slewed(now time.Time) time.Duration {
+	if r.started.IsZero() || r.Conf.Stuck {
+		return 0
+	}
+	elapsed := now.Sub(r.started)
+	if needed := r.Model.Time(r.fromAz, r.fromEl, r.toAz, r.toEl); elapsed > needed {
+		elapsed = needed
+	}
+	return elapsed
+}
+
+func (r *SimulatedRotator) Close() error {
+	return nil
+}
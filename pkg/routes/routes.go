@@ -7,9 +7,29 @@ import(
 
 func RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/", controllers.Root).Methods("GET")
+	router.HandleFunc("/time", controllers.GetTime).Methods("GET")
+	router.HandleFunc("/targets", controllers.GetTargets).Methods("GET")
 	router.HandleFunc("/record", controllers.CreateRecording).Methods("POST")
+	router.HandleFunc("/plan", controllers.PlanRecording).Methods("POST")
 	router.HandleFunc("/status", controllers.GetStatus).Methods("GET")
 	router.HandleFunc("/status/{id}", controllers.GetStatusId).Methods("GET")
+	router.HandleFunc("/queue", controllers.GetQueue).Methods("GET")
 	router.HandleFunc("/clear", controllers.ClearDatabase).Methods("GET")
 	router.HandleFunc("/download/{id}", controllers.DownloadId).Methods("GET") 
+	router.HandleFunc("/recordings/{id}", controllers.DeleteRecording).Methods("DELETE")
+	router.HandleFunc("/recordings/{id}", controllers.PatchRecording).Methods("PATCH")
+	router.HandleFunc("/recordings/{id}/cancel", controllers.CancelRecording).Methods("POST")
+	router.HandleFunc("/series", controllers.CreateSeries).Methods("POST")
+	router.HandleFunc("/series", controllers.GetAllSeries).Methods("GET")
+	router.HandleFunc("/series/{id}", controllers.GetSeriesId).Methods("GET")
+	router.HandleFunc("/series/{id}", controllers.DeleteSeries).Methods("DELETE")
+	router.HandleFunc("/series/{id}/pause", controllers.PauseSeries).Methods("POST")
+	router.HandleFunc("/series/{id}/resume", controllers.ResumeSeries).Methods("POST")
+	router.HandleFunc("/surveys", controllers.CreateSurvey).Methods("POST")
+	router.HandleFunc("/surveys", controllers.GetSurveys).Methods("GET")
+	router.HandleFunc("/surveys/{id}", controllers.GetSurveyId).Methods("GET")
+	router.HandleFunc("/surveys/{id}", controllers.DeleteSurvey).Methods("DELETE")
+	router.HandleFunc("/blackouts", controllers.CreateBlackout).Methods("POST")
+	router.HandleFunc("/blackouts", controllers.GetBlackouts).Methods("GET")
+	router.HandleFunc("/blackouts/{id}", controllers.DeleteBlackout).Methods("DELETE")
 }
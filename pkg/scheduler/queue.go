@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"carlosapi/pkg/models"
+	"container/heap"
+	"sync"
+)
+
+// Queue holds the recordings waiting to run ordered by start time, it is
+// safe for concurrent use
+type Queue struct {
+	mu    sync.Mutex
+	items recordingHeap
+	byId  map[int64]*item
+}
+
+type item struct {
+	rec   models.Recording
+	index int
+}
+
+// heap.Interface, the next recording to run first
+type recordingHeap []*item
+
+func (h recordingHeap) Len() int {
+	return len(h)
+}
+
+func (h recordingHeap) Less(i, j int) bool {
+	if h[i].rec.Time != h[j].rec.Time {
+		return h[i].rec.Time < h[j].rec.Time
+	}
+	return h[i].rec.Id < h[j].rec.Id
+}
+
+func (h recordingHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *recordingHeap) Push(x any) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *recordingHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
+
+// creates an empty queue
+func NewQueue() *Queue {
+	return &Queue{byId: map[int64]*item{}}
+}
+
+// replaces the content of the queue
+func (q *Queue) Reset(recs []models.Recording) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = make(recordingHeap, 0, len(recs))
+	q.byId = map[int64]*item{}
+	for _, rec := range recs {
+		it := &item{rec: rec, index: len(q.items)}
+		q.items = append(q.items, it)
+		q.byId[rec.Id] = it
+	}
+	heap.Init(&q.items)
+}
+
+// adds a recording or updates it if it is already queued
+func (q *Queue) Push(rec models.Recording) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if it, ok := q.byId[rec.Id]; ok {
+		it.rec = rec
+		heap.Fix(&q.items, it.index)
+		return
+	}
+	it := &item{rec: rec}
+	heap.Push(&q.items, it)
+	q.byId[rec.Id] = it
+}
+
+// removes a recording, returns false if it wasn't queued
+func (q *Queue) Remove(id int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	it, ok := q.byId[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(&q.items, it.index)
+	delete(q.byId, id)
+	return true
+}
+
+// the next recording to run without removing it
+func (q *Queue) Peek() (models.Recording, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return models.Recording{}, false
+	}
+	return q.items[0].rec, true
+}
+
+// removes and returns the next recording to run
+func (q *Queue) Pop() (models.Recording, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return models.Recording{}, false
+	}
+	it := heap.Pop(&q.items).(*item)
+	delete(q.byId, it.rec.Id)
+	return it.rec, true
+}
+
+// number of queued recordings
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// copy of the queued recordings in the order they will run
+func (q *Queue) Snapshot() []models.Recording {
+	q.mu.Lock()
+	items := make(recordingHeap, len(q.items))
+	for i, it := range q.items {
+		items[i] = &item{rec: it.rec, index: i}
+	}
+	q.mu.Unlock()
+
+	recs := make([]models.Recording, 0, len(items))
+	for items.Len() > 0 {
+		recs = append(recs, heap.Pop(&items).(*item).rec)
+	}
+	return recs
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"carlosapi/pkg/models"
+	"slices"
+	"testing"
+)
+
+func TestQueueOrder(t *testing.T) {
+	// by time, then by id for the same time
+	q := NewQueue()
+	q.Push(models.Recording{Id: 3, Time: 300})
+	q.Push(models.Recording{Id: 2, Time: 100})
+	q.Push(models.Recording{Id: 1, Time: 200})
+	q.Push(models.Recording{Id: 4, Time: 100})
+
+	want := []int64{2, 4, 1, 3}
+	if got := ids(q.Snapshot()); !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	// a snapshot leaves the queue as it was
+	if q.Len() != 4 {
+		t.Fatalf("Len() = %d after Snapshot, want 4", q.Len())
+	}
+	if rec, ok := q.Peek(); !ok || rec.Id != 2 {
+		t.Fatalf("Peek() = %v, %v, want 2", rec.Id, ok)
+	}
+	for _, id := range want {
+		if rec, ok := q.Pop(); !ok || rec.Id != id {
+			t.Fatalf("Pop() = %v, %v, want %v", rec.Id, ok, id)
+		}
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatalf("Pop() of an empty queue succeeded")
+	}
+	if _, ok := q.Peek(); ok {
+		t.Fatalf("Peek() of an empty queue succeeded")
+	}
+}
+
+func TestQueueUpdate(t *testing.T) {
+	q := NewQueue()
+	q.Push(models.Recording{Id: 1, Time: 100})
+	q.Push(models.Recording{Id: 2, Time: 200})
+	q.Push(models.Recording{Id: 3, Time: 300})
+
+	// pushing a queued recording moves it instead of adding it again
+	q.Push(models.Recording{Id: 1, Time: 400})
+	if got, want := ids(q.Snapshot()), []int64{2, 3, 1}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() after moving 1 later = %v, want %v", got, want)
+	}
+	q.Push(models.Recording{Id: 3, Time: 50})
+	if got, want := ids(q.Snapshot()), []int64{3, 2, 1}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() after moving 3 earlier = %v, want %v", got, want)
+	}
+	if q.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", q.Len())
+	}
+}
+
+func TestQueueRemove(t *testing.T) {
+	q := NewQueue()
+	for i := int64(1); i <= 6; i++ {
+		q.Push(models.Recording{Id: i, Time: 1000 - i*100})
+	}
+	// from the middle, the top and the bottom of the heap
+	for _, id := range []int64{3, 6, 1} {
+		if !q.Remove(id) {
+			t.Fatalf("Remove(%d) = false", id)
+		}
+	}
+	if q.Remove(3) {
+		t.Fatalf("Remove(3) twice = true")
+	}
+	if q.Remove(42) {
+		t.Fatalf("Remove(42) of a recording never queued = true")
+	}
+	if got, want := ids(q.Snapshot()), []int64{5, 4, 2}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	// removed ones can be queued again
+	q.Push(models.Recording{Id: 6, Time: 0})
+	if rec, _ := q.Peek(); rec.Id != 6 {
+		t.Fatalf("Peek() = %v after pushing 6 again, want 6", rec.Id)
+	}
+}
+
+func TestQueueReset(t *testing.T) {
+	q := NewQueue()
+	q.Push(models.Recording{Id: 9, Time: 1})
+	q.Reset([]models.Recording{{Id: 1, Time: 300}, {Id: 2, Time: 100}, {Id: 3, Time: 200}})
+	if got, want := ids(q.Snapshot()), []int64{2, 3, 1}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	if q.Remove(9) {
+		t.Fatalf("Remove(9) = true, it was queued before the reset")
+	}
+	// what was reset can be updated and removed
+	q.Push(models.Recording{Id: 1, Time: 0})
+	if !q.Remove(2) {
+		t.Fatalf("Remove(2) = false")
+	}
+	if got, want := ids(q.Snapshot()), []int64{1, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+}
+
+// ids of recordings in order
+func ids(recs []models.Recording) []int64 {
+	var ids []int64
+	for _, rec := range recs {
+		ids = append(ids, rec.Id)
+	}
+	return ids
+}
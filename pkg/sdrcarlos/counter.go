@@ -0,0 +1,62 @@
+package sdrcarlos
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Counter is implemented by receivers that tell how far a capture has got,
+// so what happens during it can be placed in the data file
+type Counter interface {
+	// IQ samples written to the file by the capture running, or the last
+	// one, and when the first of them was, zero if none was yet
+	Captured() (int64, time.Time)
+}
+
+// counts the bytes written to the data file of a capture, embedded in the
+// receivers to implement Counter
+type sampleCounter struct {
+	mu    sync.Mutex
+	bytes int64
+	first time.Time
+}
+
+// Captured gets the samples written by the current or last capture and when
+// the first of them was
+func (c *sampleCounter) Captured() (int64, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 2 bytes (I and Q) per sample
+	return c.bytes / 2, c.first
+}
+
+// starts counting a new capture
+func (c *sampleCounter) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bytes, c.first = 0, time.Time{}
+}
+
+// wraps the data file so what is written to it is counted
+func (c *sampleCounter) writer(w io.Writer) io.Writer {
+	return &countingWriter{w: w, c: c}
+}
+
+type countingWriter struct {
+	w io.Writer
+	c *sampleCounter
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	if n > 0 {
+		cw.c.mu.Lock()
+		if cw.c.first.IsZero() {
+			cw.c.first = time.Now()
+		}
+		cw.c.bytes += int64(n)
+		cw.c.mu.Unlock()
+	}
+	return n, err
+}
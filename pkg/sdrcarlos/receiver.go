@@ -0,0 +1,43 @@
+package sdrcarlos
+
+import (
+	"carlosapi/pkg/config"
+	"context"
+	"fmt"
+)
+
+// names of the available SDR backends (sdr_backend in config.toml)
+const (
+	BackendRTLSDR    = "rtlsdr"
+	BackendSimulated = "simulated"
+	BackendRtlTcp    = "rtltcp"
+)
+
+// Receiver is an SDR that can be enumerated, configured and used to
+// capture unsigned 8 bit interleaved IQ samples to a file
+type Receiver interface {
+	// gets connected devices, nil if there are none
+	GetDevices() []RTLDevice
+	// configures the device identified by indexID
+	Config(indexID int, samplerate int, freq int, bw int, gain int, bias bool) error
+	// captures IQ samples to filename for a period of time, stops early
+	// when ctx is cancelled keeping what was captured
+	ReadTime(ctx context.Context, filename string, milliseconds int64) error
+	// releases the device
+	Close() error
+}
+
+// returns the receiver selected by the sdr_backend configuration key,
+// defaults to a local RTL-SDR dongle
+func NewReceiver(conf config.Config) (Receiver, error) {
+	switch conf.SdrBackend {
+	case "", BackendRTLSDR:
+		return &SDRCARLOS{Debug: false}, nil
+	case BackendSimulated:
+		return NewSimulatedSDR(conf.Simulator), nil
+	case BackendRtlTcp:
+		return NewRtlTcpSDR(conf.RtlTcp), nil
+	default:
+		return nil, fmt.Errorf("Unknown SDR backend %q", conf.SdrBackend)
+	}
+}
@@ -0,0 +1,258 @@
+package sdrcarlos
+
+import (
+	"bufio"
+	"carlosapi/pkg/config"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// rtl_tcp commands, one byte followed by a big endian uint32 parameter
+const (
+	RtlTcpSetFreq       byte = 0x01
+	RtlTcpSetSampleRate byte = 0x02
+	RtlTcpSetGainMode   byte = 0x03
+	RtlTcpSetGain       byte = 0x04
+	RtlTcpSetFreqCorr   byte = 0x05
+	RtlTcpSetAgcMode    byte = 0x08
+	RtlTcpSetBiasTee    byte = 0x0e
+)
+
+// magic at the start of the dongle info header sent by rtl_tcp
+const rtlTcpMagic = "RTL0"
+
+// samples streamed right after the settings are applied are discarded for
+// this long, they may still come from the previous ones
+const rtlTcpSettle = 100 * time.Millisecond
+
+// names of the tuners reported in the dongle info header
+var rtlTunerNames = []string{"Unknown", "E4000", "FC0012", "FC0013", "FC2580", "R820T", "R828D"}
+
+// RtlTcpSDR is a client for a dongle shared over the network by rtl_tcp
+type RtlTcpSDR struct {
+	Conf  config.RtlTcpConfig
+	Debug bool
+
+	conn       net.Conn
+	reader     *bufio.Reader
+	tuner      uint32
+	gains      uint32
+	samplerate int
+	freq       int
+	gain       int
+	bias       bool
+	configured bool
+	sampleCounter
+}
+
+// creates a rtl_tcp client from the [rtltcp] configuration
+func NewRtlTcpSDR(conf config.RtlTcpConfig) *RtlTcpSDR {
+	return &RtlTcpSDR{Conf: conf}
+}
+
+// address of the rtl_tcp server
+func (u *RtlTcpSDR) address() string {
+	return net.JoinHostPort(u.Conf.Host, fmt.Sprint(u.Conf.Port))
+}
+
+// connects to the server and reads the dongle info header
+func (u *RtlTcpSDR) connect() error {
+	if u.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", u.address(), u.timeout())
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 12)
+	conn.SetReadDeadline(time.Now().Add(u.timeout()))
+	reader := bufio.NewReaderSize(conn, 64*1024)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		conn.Close()
+		return fmt.Errorf("Error reading rtl_tcp header: %v", err)
+	}
+	if string(header[:4]) != rtlTcpMagic {
+		conn.Close()
+		return fmt.Errorf("Not a rtl_tcp server at %s", u.address())
+	}
+
+	u.conn = conn
+	u.reader = reader
+	u.tuner = binary.BigEndian.Uint32(header[4:8])
+	u.gains = binary.BigEndian.Uint32(header[8:12])
+	if u.Debug {
+		log.Printf("\trtl_tcp connected to %s, tuner %s, %d gains\n", u.address(), u.tunerName(), u.gains)
+	}
+	return nil
+}
+
+// network timeout, 5 seconds by default
+func (u *RtlTcpSDR) timeout() time.Duration {
+	if u.Conf.Timeout > 0 {
+		return time.Duration(u.Conf.Timeout) * time.Millisecond
+	}
+	return 5 * time.Second
+}
+
+// name of the remote tuner
+func (u *RtlTcpSDR) tunerName() string {
+	if int(u.tuner) < len(rtlTunerNames) {
+		return rtlTunerNames[u.tuner]
+	}
+	return rtlTunerNames[0]
+}
+
+// sends a command to the server
+func (u *RtlTcpSDR) command(cmd byte, param uint32) error {
+	buf := make([]byte, 5)
+	buf[0] = cmd
+	binary.BigEndian.PutUint32(buf[1:], param)
+	u.conn.SetWriteDeadline(time.Now().Add(u.timeout()))
+	_, err := u.conn.Write(buf)
+	return err
+}
+
+// gets the remote device, nil if the server can't be reached
+func (u *RtlTcpSDR) GetDevices() []RTLDevice {
+	if err := u.connect(); err != nil {
+		log.Printf("❌ rtl_tcp connection failed: %v\n", err)
+		return nil
+	}
+	return []RTLDevice{{
+		Vendor:  "rtl_tcp",
+		Product: u.tunerName(),
+		Serial:  u.address(),
+	}}
+}
+
+// configures the remote device, the index and bandwidth can't be chosen
+// through rtl_tcp and are ignored
+func (u *RtlTcpSDR) Config(indexID int, samplerate int, freq int, bw int, gain int, bias bool) (err error) {
+	if err = u.connect(); err != nil {
+		return
+	}
+	u.samplerate, u.freq, u.gain, u.bias = samplerate, freq, gain, bias
+	if err = u.apply(); err != nil {
+		return
+	}
+	u.configured = true
+	return
+}
+
+// sends the settings to the server
+func (u *RtlTcpSDR) apply() error {
+	var biasParam uint32
+	if u.bias {
+		biasParam = 1
+	}
+	commands := []struct {
+		cmd   byte
+		param uint32
+	}{
+		{RtlTcpSetSampleRate, uint32(u.samplerate)},
+		{RtlTcpSetFreq, uint32(u.freq)},
+		{RtlTcpSetAgcMode, 0},
+		{RtlTcpSetGainMode, 1},
+		{RtlTcpSetGain, uint32(u.gain)},
+		{RtlTcpSetBiasTee, biasParam},
+	}
+	for _, c := range commands {
+		if err := u.command(c.cmd, c.param); err != nil {
+			u.Close()
+			if u.Debug {
+				log.Printf("\trtl_tcp command 0x%02x Failed - error: %s\n", c.cmd, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// starts a fresh stream: the samples that piled up in the socket and the
+// server since the last capture, maybe at another pointing, are dropped
+// by reconnecting, the settings sent again and what comes during the
+// settle time discarded
+func (u *RtlTcpSDR) restart() error {
+	u.Close()
+	if err := u.connect(); err != nil {
+		return err
+	}
+	if err := u.apply(); err != nil {
+		return err
+	}
+
+	buffer := make([]byte, 16*1024)
+	settled := time.Now().Add(rtlTcpSettle)
+	for time.Now().Before(settled) {
+		u.conn.SetReadDeadline(settled)
+		if _, err := u.reader.Read(buffer); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				break
+			}
+			u.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadTime captures the samples streamed for a period of time
+func (u *RtlTcpSDR) ReadTime(ctx context.Context, filename string, milliseconds int64) error {
+	if !u.configured {
+		return fmt.Errorf("rtl_tcp not configured")
+	}
+	if u.Debug {
+		log.Println("Entered RtlTcpSDR ReadTime() ...")
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = u.restart(); err != nil {
+		return fmt.Errorf("rtl_tcp restart failed: %v", err)
+	}
+	u.reset()
+	out := u.writer(f)
+
+	// 2 bytes (I and Q) per sample, in blocks of 100 ms so it can be
+	// cancelled
+	size := int64(u.samplerate) * milliseconds / 1000 * 2
+	block := int64(u.samplerate) / 10 * 2
+	if block < 2 {
+		block = 2
+	}
+	u.conn.SetReadDeadline(time.Now().Add(time.Duration(milliseconds)*time.Millisecond + u.timeout()))
+	for n := int64(0); n < size && ctx.Err() == nil; {
+		copied, err := io.CopyN(out, u.reader, min(block, size-n))
+		n += copied
+		if err != nil {
+			u.Close()
+			return fmt.Errorf("rtl_tcp read failed after %d bytes: %v", n, err)
+		}
+	}
+	if u.Debug {
+		log.Println("End ReadTime() ...")
+	}
+	return f.Close()
+}
+
+// closes the connection to the server
+func (u *RtlTcpSDR) Close() error {
+	if u.conn == nil {
+		return nil
+	}
+	err := u.conn.Close()
+	u.conn = nil
+	u.reader = nil
+	return err
+}
@@ -0,0 +1,152 @@
+package sdrcarlos
+
+import (
+	"bufio"
+	"carlosapi/pkg/config"
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// RtlTcpCommand is a command received by the stand-in server
+type RtlTcpCommand struct {
+	Cmd   byte
+	Param uint32
+}
+
+// RtlTcpServer is a small rtl_tcp stand-in that streams simulated IQ
+// samples, used to exercise the rtl_tcp backend without a dongle
+type RtlTcpServer struct {
+	Listener net.Listener
+	Debug    bool
+
+	conf     config.SimulatorConfig
+	mu       sync.Mutex
+	commands []RtlTcpCommand
+	conns    map[net.Conn]bool
+	wg       sync.WaitGroup
+}
+
+// listens on addr ("127.0.0.1:0" for a random port), call Serve to accept
+// connections
+func NewRtlTcpServer(addr string, conf config.SimulatorConfig) (*RtlTcpServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &RtlTcpServer{Listener: listener, conf: conf, conns: map[net.Conn]bool{}}, nil
+}
+
+// address the server is listening on
+func (s *RtlTcpServer) Addr() *net.TCPAddr {
+	return s.Listener.Addr().(*net.TCPAddr)
+}
+
+// commands received so far
+func (s *RtlTcpServer) Commands() []RtlTcpCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RtlTcpCommand(nil), s.commands...)
+}
+
+// accepts connections until the server is closed
+func (s *RtlTcpServer) Serve() {
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// stops listening, disconnects the clients and waits for them to go away
+func (s *RtlTcpServer) Close() error {
+	err := s.Listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// sends the dongle info header and streams samples while applying the
+// commands sent by the client
+func (s *RtlTcpServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	// an R820T with 29 gains like most dongles
+	header := make([]byte, 12)
+	copy(header, rtlTcpMagic)
+	binary.BigEndian.PutUint32(header[4:8], 5)
+	binary.BigEndian.PutUint32(header[8:12], 29)
+	if _, err := conn.Write(header); err != nil {
+		return
+	}
+
+	var mu sync.Mutex
+	sim := NewSimulatedSDR(s.conf)
+	samplerate, freq, gain := 2048000, 1420000000, 0
+	sim.Config(0, samplerate, freq, 0, gain, false)
+
+	// commands
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 5)
+		for {
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			cmd := RtlTcpCommand{Cmd: buf[0], Param: binary.BigEndian.Uint32(buf[1:])}
+			if s.Debug {
+				log.Printf("\trtl_tcp server command 0x%02x %d\n", cmd.Cmd, cmd.Param)
+			}
+			s.mu.Lock()
+			s.commands = append(s.commands, cmd)
+			s.mu.Unlock()
+
+			mu.Lock()
+			switch cmd.Cmd {
+			case RtlTcpSetFreq:
+				freq = int(cmd.Param)
+			case RtlTcpSetSampleRate:
+				samplerate = int(cmd.Param)
+			case RtlTcpSetGain:
+				gain = int(cmd.Param)
+			}
+			sim.Config(0, samplerate, freq, 0, gain, false)
+			mu.Unlock()
+		}
+	}()
+
+	// samples
+	w := bufio.NewWriterSize(conn, 16*1024)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		mu.Lock()
+		sim.synthesize(w, 8*1024)
+		mu.Unlock()
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
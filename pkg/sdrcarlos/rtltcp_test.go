@@ -0,0 +1,98 @@
+package sdrcarlos
+
+import (
+	"carlosapi/pkg/config"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// starts a stand-in server and a client connected to it
+func testRtlTcp(t *testing.T) (*RtlTcpServer, *RtlTcpSDR) {
+	t.Helper()
+	server, err := NewRtlTcpServer("127.0.0.1:0", testSimulatorConfig(7))
+	if err != nil {
+		t.Fatalf("NewRtlTcpServer: %v", err)
+	}
+	go server.Serve()
+	client := NewRtlTcpSDR(config.RtlTcpConfig{Host: "127.0.0.1", Port: server.Addr().Port, Timeout: 2000})
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+func TestRtlTcpHeader(t *testing.T) {
+	_, client := testRtlTcp(t)
+	devices := client.GetDevices()
+	if len(devices) != 1 {
+		t.Fatalf("got %d devices, want 1", len(devices))
+	}
+	if devices[0].Product != "R820T" {
+		t.Errorf("tuner %q, want R820T", devices[0].Product)
+	}
+	if client.gains != 29 {
+		t.Errorf("%d gains, want 29", client.gains)
+	}
+}
+
+func TestRtlTcpCapture(t *testing.T) {
+	server, client := testRtlTcp(t)
+	if err := client.Config(0, 250000, 1420500000, 0, 150, true); err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	filename := filepath.Join(t.TempDir(), "capture.iq")
+	start := time.Now()
+	if err := client.ReadTime(context.Background(), filename, 200); err != nil {
+		t.Fatalf("ReadTime: %v", err)
+	}
+
+	// 200 ms at 250 kS/s, 2 bytes per sample
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() != 100000 {
+		t.Errorf("captured %d bytes, want 100000", info.Size())
+	}
+	// counted from the first sample kept, after the settings settle
+	n, first := client.Captured()
+	if n != 50000 {
+		t.Errorf("counted %d samples, want 50000", n)
+	}
+	if first.Sub(start) < rtlTcpSettle {
+		t.Errorf("first sample %v after the start, want at least %v", first.Sub(start), rtlTcpSettle)
+	}
+
+	// the settings are sent when configuring and again on the fresh
+	// connection of the capture, the stand-in may miss the first ones as
+	// that connection is dropped right away
+	want := []RtlTcpCommand{
+		{RtlTcpSetSampleRate, 250000},
+		{RtlTcpSetFreq, 1420500000},
+		{RtlTcpSetAgcMode, 0},
+		{RtlTcpSetGainMode, 1},
+		{RtlTcpSetGain, 150},
+		{RtlTcpSetBiasTee, 1},
+	}
+	got := server.Commands()
+	if len(got) < len(want) {
+		t.Fatalf("server got %d commands, want at least %d: %v", len(got), len(want), got)
+	}
+	for i, cmd := range got[len(got)-len(want):] {
+		if cmd != want[i] {
+			t.Errorf("command %d of the capture is %+v, want %+v", i, cmd, want[i])
+		}
+	}
+}
+
+func TestRtlTcpNotConfigured(t *testing.T) {
+	_, client := testRtlTcp(t)
+	filename := filepath.Join(t.TempDir(), "capture.iq")
+	if err := client.ReadTime(context.Background(), filename, 100); err == nil {
+		t.Fatalf("ReadTime without Config succeeded")
+	}
+}
@@ -1,13 +1,12 @@
 package sdrcarlos
 
 import (
+	"context"
 	//"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
 	"time"
 	
 	rtl "github.com/jpoirier/gortlsdr"
@@ -24,6 +23,7 @@ type SDRCARLOS struct {
 	Dev *rtl.Context
 	Wg  *sync.WaitGroup
 	Debug bool
+	sampleCounter
 }
 
 // gets connected devices
@@ -85,17 +85,22 @@ func (u *SDRCARLOS) Read(filename string) {
 }
 
 // ReadTime does syncronous read for a period of time
-func (u *SDRCARLOS) ReadTime(filename string, milliseconds int64) {
+func (u *SDRCARLOS) ReadTime(ctx context.Context, filename string, milliseconds int64) error {
 	if u.Debug {
 		log.Println("Entered SDRCARLOS ReadTime() ...")
 	}
+	if u.Dev == nil {
+		return fmt.Errorf("SDR not configured")
+	}
 
 	// create file
-    f, err := os.Create(filename)
-    if err != nil {
-		log.Fatal(err)
-    }
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
 	defer f.Close()
+	u.reset()
+	out := u.writer(f)
 	
 	var readCnt uint64
 	//var buffer = make([]uint8, rtl.DefaultBufLength)
@@ -108,22 +113,22 @@ func (u *SDRCARLOS) ReadTime(filename string, milliseconds int64) {
 	for {
 		nRead, err := u.Dev.ReadSync(buffer, 1024)
 		if err != nil {
-			// log.Printf("\tReadSync Failed - error: %s\n", err)
-			break
+			return fmt.Errorf("ReadSync failed: %v", err)
 		}
 		// log.Printf("\tReadSync %d\n", nRead)
 		if nRead > 0 {
-			// buf := buffer[:nRead]
 			if u.Debug {
 				fmt.Printf("\rnRead %d: readCnt: %d", nRead, readCnt)
 			}
 			readCnt++
-			_, err = f.Write(buffer)
+			_, err = out.Write(buffer[:nRead])
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-
-
+		}
+		// cancelled?
+		if ctx.Err() != nil {
+			break
 		}
 		// check time
 		t := time.Now()
@@ -135,7 +140,7 @@ func (u *SDRCARLOS) ReadTime(filename string, milliseconds int64) {
 	if u.Debug {
 		log.Println("End ReadTime() ...")
 	}
-	//u.Wg.Done()
+	return f.Close()
 }
 
 // shutdown
@@ -155,6 +160,16 @@ func (u *SDRCARLOS) Shutdown() {
 	}
 }
 
+// Close releases the device
+func (u *SDRCARLOS) Close() error {
+	if u.Dev == nil {
+		return nil
+	}
+	err := u.Dev.Close()
+	u.Dev = nil
+	return err
+}
+
 // sdrConfig configures the Device.
 func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain int, bias bool) (err error) {
 	if u.Dev, err = rtl.Open(indexID); err != nil {
@@ -170,7 +185,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	//---------- Set Tuner Gain ----------
 	err = u.Dev.SetTunerGainMode(true)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetTunerGainMode Failed - error: %s\n", err)
 		}
@@ -195,7 +210,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 
 	err = u.Dev.SetTunerGain(gain)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetTunerGain Failed - error: %s\n", err)
 		}
@@ -209,7 +224,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	//samplerate := 2083334
 	err = u.Dev.SetSampleRate(samplerate)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetSampleRate Failed - error: %s\n", err)
 		}
@@ -223,7 +238,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	//---------- Get/Set Center Freq ----------
 	err = u.Dev.SetCenterFreq(freq)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetCenterFreq Failed, error: %s\n", err)
 		}
@@ -239,7 +254,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 		log.Printf("\tSetting Bandwidth: %d\n", bw)
 	}
 	if err = u.Dev.SetTunerBw(bw); err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetTunerBw %d Failed, error: %s\n", bw, err)
 		}
@@ -250,7 +265,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	}
 
 	if err = u.Dev.ResetBuffer(); err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tResetBuffer Failed - error: %s\n", err)
 		}
@@ -267,7 +282,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	}
 	err = u.Dev.SetFreqCorrection(freqCorr)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("\tSetFreqCorrection %d Failed, error: %s\n", freqCorr, err)
 		}
@@ -280,7 +295,7 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	// Bias-T
 	err = u.Dev.SetBiasTee(true)
 	if err != nil {
-		u.Dev.Close()
+		u.Close()
 		if u.Debug {
 			log.Printf("SetBiasTee %v Failed, error %s\n", bias, err)
 		}
@@ -289,11 +304,3 @@ func (u *SDRCARLOS) Config(indexID int, samplerate int, freq int, bw int, gain i
 	return
 }
 
-// sigAbort
-func (u *SDRCARLOS) SigAbort() {
-	ch := make(chan os.Signal)
-	signal.Notify(ch, syscall.SIGINT)
-	<-ch
-	u.Shutdown()
-	os.Exit(0)
-}
@@ -0,0 +1,218 @@
+package sdrcarlos
+
+import (
+	"bufio"
+	"carlosapi/pkg/config"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// rest frequency of the neutral hydrogen line in Hz
+const HydrogenLineFreq = 1420405752
+
+// SimulatedSDR synthesizes IQ data instead of reading it from a dongle.
+// The output is noise plus the configured tones plus a hydrogen line bump
+// that is stronger the closer the antenna points to the hydrogen hot spot.
+// Given the same seed and the same sequence of calls the output is the same.
+type SimulatedSDR struct {
+	Conf  config.SimulatorConfig
+	Debug bool
+
+	rng        *rand.Rand
+	samplerate int
+	freq       int
+	gain       int
+	configured bool
+
+	// where the antenna points, may change during a capture
+	pointing sync.Mutex
+	az       float32
+	el       float32
+
+	// synthesis state, the sample counter and the hydrogen line filter
+	n   int64
+	hiI float64
+	hiQ float64
+
+	// samples written by the capture
+	sampleCounter
+}
+
+// Pointer is implemented by receivers whose output depends on where the
+// antenna is pointing
+type Pointer interface {
+	SetPointing(az float32, el float32)
+}
+
+// creates a simulated receiver from the [simulator] configuration
+func NewSimulatedSDR(conf config.SimulatorConfig) *SimulatedSDR {
+	return &SimulatedSDR{Conf: conf}
+}
+
+// gets the simulated device
+func (s *SimulatedSDR) GetDevices() []RTLDevice {
+	return []RTLDevice{{
+		Vendor:  "CARLOS",
+		Product: "Simulated SDR",
+		Serial:  "SIM00000001",
+	}}
+}
+
+// configures the simulated device, bandwidth and bias-tee are ignored
+func (s *SimulatedSDR) Config(indexID int, samplerate int, freq int, bw int, gain int, bias bool) error {
+	if indexID != 0 {
+		return fmt.Errorf("No simulated device with index %d", indexID)
+	}
+	if samplerate <= 0 {
+		return fmt.Errorf("Invalid sample rate %d", samplerate)
+	}
+	s.samplerate = samplerate
+	s.freq = freq
+	s.gain = gain
+	s.rng = rand.New(rand.NewSource(s.Conf.Seed))
+	s.n, s.hiI, s.hiQ = 0, 0, 0
+	s.configured = true
+	if s.Debug {
+		log.Printf("\tSimulatedSDR configured: rate %d, freq %d, gain %d\n", samplerate, freq, gain)
+	}
+	return nil
+}
+
+// sets the direction the antenna is pointing to
+func (s *SimulatedSDR) SetPointing(az float32, el float32) {
+	s.pointing.Lock()
+	defer s.pointing.Unlock()
+	s.az = az
+	s.el = el
+}
+
+// ReadTime synthesizes the samples of a capture of the given duration
+func (s *SimulatedSDR) ReadTime(ctx context.Context, filename string, milliseconds int64) error {
+	if !s.configured {
+		return fmt.Errorf("SimulatedSDR not configured")
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s.reset()
+	w := bufio.NewWriter(s.writer(f))
+
+	// in blocks of 100 ms so it can be cancelled
+	samples := int64(s.samplerate) * milliseconds / 1000
+	block := int64(s.samplerate) / 10
+	if block < 1 {
+		block = 1
+	}
+	start := time.Now()
+	for done := int64(0); done < samples && ctx.Err() == nil; done += block {
+		s.synthesize(w, min(block, samples-done))
+
+		// optionally take as long as a real capture would
+		if s.Conf.Realtime {
+			elapsed := time.Duration(float64(done+block) / float64(s.samplerate) * float64(time.Second))
+			select {
+			case <-time.After(elapsed - time.Since(start)):
+			case <-ctx.Done():
+			}
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if s.Debug {
+		log.Printf("SimulatedSDR wrote %d samples to %s\n", samples, filename)
+	}
+	return f.Close()
+}
+
+// writes a number of interleaved IQ samples, carrying on from the previous call
+func (s *SimulatedSDR) synthesize(w io.ByteWriter, samples int64) {
+	fs := float64(s.samplerate)
+
+	// linear gain in tenths of dB like the rtl-sdr API
+	gain := math.Pow(10, float64(s.gain)/200)
+	noise := s.Conf.NoiseFloor * gain
+
+	// hydrogen line: complex noise low pass filtered to the line width
+	// and shifted to its offset from the center frequency
+	hiOffset := float64(HydrogenLineFreq - s.freq)
+	hiAmp := s.HydrogenStrength() * gain
+	hiAlpha := math.Exp(-2 * math.Pi * s.Conf.HydrogenWidth / fs)
+	hiNorm := math.Sqrt(1 - hiAlpha*hiAlpha)
+	if s.Conf.HydrogenWidth <= 0 || math.Abs(hiOffset) >= fs/2 {
+		hiAmp = 0
+	}
+
+	for end := s.n + samples; s.n < end; s.n++ {
+		t := float64(s.n) / fs
+		i := s.rng.NormFloat64() * noise
+		q := s.rng.NormFloat64() * noise
+
+		for _, tone := range s.Conf.Tones {
+			phase := 2 * math.Pi * tone.Offset * t
+			i += tone.Amplitude * gain * math.Cos(phase)
+			q += tone.Amplitude * gain * math.Sin(phase)
+		}
+
+		if hiAmp > 0 {
+			s.hiI = hiAlpha*s.hiI + hiNorm*s.rng.NormFloat64()
+			s.hiQ = hiAlpha*s.hiQ + hiNorm*s.rng.NormFloat64()
+			phase := 2 * math.Pi * hiOffset * t
+			c, sn := math.Cos(phase), math.Sin(phase)
+			i += hiAmp * (s.hiI*c - s.hiQ*sn)
+			q += hiAmp * (s.hiI*sn + s.hiQ*c)
+		}
+
+		w.WriteByte(quantize(i))
+		w.WriteByte(quantize(q))
+	}
+}
+
+// amplitude of the hydrogen line at the current pointing, a gaussian beam
+// around the configured hot spot, nothing below the horizon
+func (s *SimulatedSDR) HydrogenStrength() float64 {
+	s.pointing.Lock()
+	az, el := s.az, s.el
+	s.pointing.Unlock()
+	if el < 0 || s.Conf.HydrogenBeam <= 0 {
+		return 0
+	}
+	d := angularSeparation(float64(az), float64(el), s.Conf.HydrogenAz, s.Conf.HydrogenEl)
+	w := s.Conf.HydrogenBeam
+	return s.Conf.HydrogenAmplitude * math.Exp(-d*d/(2*w*w))
+}
+
+// releases the simulated device
+func (s *SimulatedSDR) Close() error {
+	s.configured = false
+	return nil
+}
+
+// angle in degrees between two az/el directions
+func angularSeparation(az1, el1, az2, el2 float64) float64 {
+	const rad = math.Pi / 180
+	c := math.Sin(el1*rad)*math.Sin(el2*rad) + math.Cos(el1*rad)*math.Cos(el2*rad)*math.Cos((az1-az2)*rad)
+	return math.Acos(math.Max(-1, math.Min(1, c))) / rad
+}
+
+// converts a sample to the unsigned 8 bit representation of the rtl-sdr
+func quantize(x float64) uint8 {
+	v := math.Round(127.5 + x)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,104 @@
+package sdrcarlos
+
+import (
+	"bytes"
+	"carlosapi/pkg/config"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a simulator with noise, a tone and the hydrogen line
+func testSimulatorConfig(seed int64) config.SimulatorConfig {
+	return config.SimulatorConfig{
+		Seed:              seed,
+		NoiseFloor:        5,
+		Tones:             []config.SimTone{{Offset: 10000, Amplitude: 20}},
+		HydrogenAmplitude: 30,
+		HydrogenWidth:     5000,
+		HydrogenAz:        180,
+		HydrogenEl:        45,
+		HydrogenBeam:      10,
+	}
+}
+
+// captures with a new simulated receiver and returns the bytes written
+func simulatedCapture(t *testing.T, conf config.SimulatorConfig, name string) []byte {
+	t.Helper()
+	sim := NewSimulatedSDR(conf)
+	if err := sim.Config(0, 250000, 1420000000, 0, 100, false); err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	sim.SetPointing(180, 45)
+	filename := filepath.Join(t.TempDir(), name)
+	if err := sim.ReadTime(context.Background(), filename, 100); err != nil {
+		t.Fatalf("ReadTime: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return data
+}
+
+func TestSimulatedSDRDeterministic(t *testing.T) {
+	first := simulatedCapture(t, testSimulatorConfig(42), "first.iq")
+	second := simulatedCapture(t, testSimulatorConfig(42), "second.iq")
+
+	// 100 ms at 250 kS/s, 2 bytes per sample
+	if len(first) != 50000 {
+		t.Fatalf("captured %d bytes, want 50000", len(first))
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("captures with the same seed differ")
+	}
+
+	other := simulatedCapture(t, testSimulatorConfig(43), "other.iq")
+	if bytes.Equal(first, other) {
+		t.Fatalf("captures with different seeds are the same")
+	}
+}
+
+func TestSimulatedSDRHydrogenBeam(t *testing.T) {
+	sim := NewSimulatedSDR(testSimulatorConfig(1))
+	sim.SetPointing(180, 45)
+	peak := sim.HydrogenStrength()
+	sim.SetPointing(180, 25)
+	off := sim.HydrogenStrength()
+	sim.SetPointing(180, -5)
+	below := sim.HydrogenStrength()
+
+	if peak != 30 {
+		t.Errorf("strength on the hot spot %v, want 30", peak)
+	}
+	if off >= peak || off <= 0 {
+		t.Errorf("strength 20 degrees off %v, want between 0 and %v", off, peak)
+	}
+	if below != 0 {
+		t.Errorf("strength below the horizon %v, want 0", below)
+	}
+}
+
+func TestSimulatedSDRCounts(t *testing.T) {
+	sim := NewSimulatedSDR(testSimulatorConfig(42))
+	if err := sim.Config(0, 250000, 1420000000, 0, 100, false); err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	dir := t.TempDir()
+	if err := sim.ReadTime(context.Background(), filepath.Join(dir, "first.iq"), 100); err != nil {
+		t.Fatalf("ReadTime: %v", err)
+	}
+	n, first := sim.Captured()
+	if n != 25000 || first.IsZero() {
+		t.Fatalf("counted %d samples from %v, want 25000 from the capture", n, first)
+	}
+
+	// a new capture counts from 0
+	if err := sim.ReadTime(context.Background(), filepath.Join(dir, "second.iq"), 40); err != nil {
+		t.Fatalf("ReadTime: %v", err)
+	}
+	if n, second := sim.Captured(); n != 10000 || second.Before(first) {
+		t.Fatalf("counted %d samples from %v, want 10000 from after %v", n, second, first)
+	}
+}
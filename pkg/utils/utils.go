@@ -29,7 +29,8 @@ func CreateArchive(name string, directory string, files []string) error {
 	// Create output file
 	out, err := os.Create(name)
 	if err != nil {
-		log.Printf("❌ Error writing archive: ", err)
+		log.Printf("❌ Error writing archive: %v", err)
+		return err
 	}
 	defer out.Close()
 	
@@ -50,7 +51,14 @@ func CreateArchive(name string, directory string, files []string) error {
 		}
 	}
 
-	return nil
+	// flush everything so write errors are not lost
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
 }
 
 func AddToArchive(tw *tar.Writer, filename string) error {